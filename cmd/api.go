@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+
+	"telegraphcli/pkg/metrics"
+)
+
+// telegraphURL is the base URL of published pages
+const telegraphURL = "https://telegra.ph/"
+
+// apiStats counts the errors returned by API calls while serve metrics runs
+var apiStats *metrics.APIStats
+
+// observeError counts an error returned by an API call and passes it on
+func observeError(method string, err error) error {
+	if err != nil && apiStats != nil {
+		apiStats.Error(method)
+	}
+	return err
+}
+
+// newAPIClient returns an HTTP client that sends the telegraphcl user agent
+func newAPIClient() *http.Client {
+	return &http.Client{
+		Timeout: httpClient.Timeout,
+		Transport: &customTransport{
+			base:      httpClient.Transport,
+			userAgent: userAgent,
+		},
+	}
+}
+
+// pageListLimit is the largest page count GetPageList returns per call
+const pageListLimit = 200
+
+// fetchPage gets a page by path, optionally with its content
+func fetchPage(ctx context.Context, path string, withContent bool) (*telegraph.Page, error) {
+	getPage := telegraph.GetPage{
+		Path:          path,
+		ReturnContent: withContent,
+	}
+
+	var page *telegraph.Page
+	err := retry(func() error {
+		var e error
+		page, e = getPage.Do(ctx, newAPIClient())
+		return observeError("getPage", e)
+	}, 3)
+
+	return page, err
+}
+
+// fetchAllPages calls GetPageList until every page of the account is fetched
+func fetchAllPages(ctx context.Context, accessToken string) ([]telegraph.Page, error) {
+	var pages []telegraph.Page
+	err := forEachPage(ctx, accessToken, func(batch []telegraph.Page, total uint) error {
+		pages = append(pages, batch...)
+		return nil
+	})
+	return pages, err
+}
+
+// forEachPage calls GetPageList until every page of the account is fetched,
+// passing each batch to fn along with the account's total page count
+func forEachPage(ctx context.Context, accessToken string, fn func(batch []telegraph.Page, total uint) error) error {
+	fetched := 0
+	for {
+		getPageList := telegraph.GetPageList{
+			AccessToken: accessToken,
+			Offset:      uint(fetched),
+			Limit:       pageListLimit,
+		}
+
+		var pageList *telegraph.PageList
+		err := retry(func() error {
+			var e error
+			pageList, e = getPageList.Do(ctx, newAPIClient())
+			return observeError("getPageList", e)
+		}, 3)
+		if err != nil {
+			return err
+		}
+
+		fetched += len(pageList.Pages)
+		if err := fn(pageList.Pages, pageList.TotalCount); err != nil {
+			return err
+		}
+		if len(pageList.Pages) == 0 || fetched >= int(pageList.TotalCount) {
+			return nil
+		}
+	}
+}
+
+// titleOf returns the title of a page, or "" if it has none
+func titleOf(page *telegraph.Page) string {
+	if page.Title == nil {
+		return ""
+	}
+	return page.Title.String()
+}
+
+// authorOf returns the author name of a page, or "" if it has none
+func authorOf(page *telegraph.Page) string {
+	if page.AuthorName == nil {
+		return ""
+	}
+	return page.AuthorName.String()
+}
+
+// fetchAccount gets the given fields of the account
+func fetchAccount(ctx context.Context, accessToken string, fields ...telegraph.AccountField) (*telegraph.Account, error) {
+	getAccountInfo := telegraph.GetAccountInfo{
+		AccessToken: accessToken,
+		Fields:      fields,
+	}
+
+	var account *telegraph.Account
+	err := retry(func() error {
+		var e error
+		account, e = getAccountInfo.Do(ctx, newAPIClient())
+		return observeError("getAccountInfo", e)
+	}, 3)
+
+	return account, err
+}
+
+// savePage edits the page at path, or creates a new page when path is empty.
+// The returned page includes the content as stored by Telegraph.
+func savePage(ctx context.Context, accessToken, path, title string, nodes []telegraph.Node) (*telegraph.Page, error) {
+	pageTitle, err := telegraph.NewTitle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	var page *telegraph.Page
+	err = retry(func() error {
+		var e error
+		if path == "" {
+			createPage := telegraph.CreatePage{
+				AccessToken:   accessToken,
+				Title:         *pageTitle,
+				Content:       nodes,
+				ReturnContent: true,
+			}
+			page, e = createPage.Do(ctx, newAPIClient())
+			return observeError("createPage", e)
+		}
+		editPage := telegraph.EditPage{
+			AccessToken:   accessToken,
+			Path:          path,
+			Title:         *pageTitle,
+			Content:       nodes,
+			ReturnContent: true,
+		}
+		page, e = editPage.Do(ctx, newAPIClient())
+		return observeError("editPage", e)
+	}, 3)
+
+	return page, err
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"telegraphcli/pkg/bulk"
+	"telegraphcli/pkg/token"
+)
+
+// addBulkFlags adds the flags that select the pages a command works on and
+// how many are processed at the same time
+func addBulkFlags(c *cobra.Command) {
+	c.Flags().String("from-file", "", "Read page paths or URLs from a file, one per line (- for stdin)")
+	c.Flags().Bool("list", false, "Work on the pages of your account selected by the page list filters")
+	c.Flags().Int("workers", 4, "Number of pages processed at the same time")
+	addFilterFlags(c)
+}
+
+// isBulk reports whether a command works on a list of pages rather than the
+// single path given as its argument
+func isBulk(cmd *cobra.Command, args []string) bool {
+	fromFile, _ := cmd.Flags().GetString("from-file")
+	list, _ := cmd.Flags().GetBool("list")
+	return fromFile != "" || list || len(args) != 1 || args[0] == "-"
+}
+
+// readsStdin reports whether pagePaths reads the page list from stdin, which
+// leaves nothing to read a confirmation from
+func readsStdin(cmd *cobra.Command, args []string) bool {
+	fromFile, _ := cmd.Flags().GetString("from-file")
+	return fromFile == "-" || (len(args) == 1 && args[0] == "-")
+}
+
+// pagePaths returns the pages a command works on: its arguments, the lines
+// of --from-file, stdin when the only argument is -, and the pages matched
+// by --list. Page URLs are reduced to paths and duplicates are dropped.
+func pagePaths(ctx context.Context, cmd *cobra.Command, args []string) ([]string, error) {
+	var paths []string
+
+	if len(args) == 1 && args[0] == "-" {
+		lines, err := readPathLines(cmd.InOrStdin())
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, lines...)
+	} else {
+		paths = append(paths, args...)
+	}
+
+	if fromFile, _ := cmd.Flags().GetString("from-file"); fromFile != "" {
+		var r io.Reader = cmd.InOrStdin()
+		if fromFile != "-" {
+			f, err := os.Open(fromFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %v", fromFile, err)
+			}
+			defer f.Close()
+			r = f
+		}
+		lines, err := readPathLines(r)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, lines...)
+	}
+
+	if list, _ := cmd.Flags().GetBool("list"); list {
+		listed, err := listedPaths(ctx, cmd)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, listed...)
+	}
+
+	seen := map[string]bool{}
+	var unique []string
+	for _, p := range paths {
+		p = pathFromArg(p)
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		unique = append(unique, p)
+	}
+	if len(unique) == 0 {
+		return nil, fmt.Errorf("no pages given")
+	}
+	return unique, nil
+}
+
+// listedPaths returns the paths of the account's pages selected by the
+// page list filter flags
+func listedPaths(ctx context.Context, cmd *cobra.Command) ([]string, error) {
+	filter, err := newPageFilter(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := token.GetToken()
+	if err != nil {
+		return nil, err
+	}
+	pages, err := fetchAllPages(ctx, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page list: %v", err)
+	}
+
+	var paths []string
+	for i := range pages {
+		if filter.Match(&pages[i]) {
+			paths = append(paths, pages[i].Path)
+		}
+	}
+	return paths, nil
+}
+
+// readPathLines reads one path per line, skipping blank lines and # comments
+func readPathLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// pathFromArg turns a page URL into its path and leaves paths unchanged
+func pathFromArg(arg string) string {
+	arg = strings.TrimSpace(arg)
+	if u, err := url.Parse(arg); err == nil && u.Host != "" {
+		return strings.Trim(u.Path, "/")
+	}
+	return strings.Trim(arg, "/")
+}
+
+// runPool calls fn for every path on a bounded worker pool, showing progress
+// on a terminal
+func runPool(ctx context.Context, cmd *cobra.Command, label string, paths []string, fn bulk.Func) []bulk.Result {
+	workers, _ := cmd.Flags().GetInt("workers")
+
+	if !isTerminal(cmd.ErrOrStderr()) {
+		return bulk.Run(ctx, paths, workers, fn, nil)
+	}
+	p := bulk.NewProgress(cmd.ErrOrStderr(), label, len(paths))
+	defer p.Finish()
+	return bulk.Run(ctx, paths, workers, fn, p.Update)
+}
+
+// runBulk runs fn for every path with runPool and prints a summary of the
+// results. It returns the number of failures.
+func runBulk(ctx context.Context, cmd *cobra.Command, label string, paths []string, fn bulk.Func) int {
+	results := runPool(ctx, cmd, label, paths, fn)
+	bulk.WriteSummary(cmd.OutOrStdout(), results)
+	return bulk.Failed(results)
+}
+
+// isTerminal reports whether w is a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
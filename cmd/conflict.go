@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+
+	"telegraphcli/pkg/diff"
+	"telegraphcli/pkg/render"
+	"telegraphcli/pkg/state"
+)
+
+// conflictError reports a page that was changed on telegra.ph after it was
+// last published from here
+type conflictError struct {
+	Path   string
+	Remote []telegraph.Node
+	// Local is the content that was about to be published, when known
+	Local []telegraph.Node
+}
+
+func (e *conflictError) Error() string {
+	return fmt.Sprintf("page %s was changed on telegra.ph since it was last published; use --force to overwrite it", e.Path)
+}
+
+// contentHash returns a hash of page content that does not depend on how
+// telegra.ph URLs are written or how text is split into nodes
+func contentHash(nodes []telegraph.Node) string {
+	sum := sha256.Sum256([]byte(render.Canonical(nodes, render.Options{URL: relativeTelegraphURL})))
+	return hex.EncodeToString(sum[:])
+}
+
+// recordHash stores the content hash of a page returned by Telegraph
+func recordHash(st *state.State, page *telegraph.Page) {
+	st.SetHash(page.Path, contentHash(page.Content))
+}
+
+// checkConflict compares the live content of a page with the hash recorded
+// when it was last published. Pages without a recorded hash are not checked.
+func checkConflict(st *state.State, page *telegraph.Page) error {
+	recorded, ok := st.Hash(page.Path)
+	if !ok || recorded == contentHash(page.Content) {
+		return nil
+	}
+	return &conflictError{Path: page.Path, Remote: page.Content}
+}
+
+// reportConflict shows how the live page differs from the local content and
+// optionally writes the live version next to the local file for merging
+func reportConflict(cmd *cobra.Command, conflict *conflictError, file string, local []telegraph.Node, writeRemote bool) {
+	remoteText, _ := normalizeContent(conflict.Remote, "markdown")
+	localText, _ := normalizeContent(local, "markdown")
+	color, _ := useColor("auto")
+	cmd.PrintErr(diff.Unified(telegraphURL+conflict.Path, file, diff.Lines(remoteText), diff.Lines(localText), diff.Options{Context: 3, Color: color}))
+
+	if !writeRemote {
+		return
+	}
+	remoteFile := remotePath(file, conflict.Path)
+	content := render.Markdown(conflict.Remote, render.Options{URL: absoluteURL})
+	if err := os.WriteFile(remoteFile, []byte(content), 0644); err != nil {
+		cmd.PrintErrf("Failed to write %s: %v\n", remoteFile, err)
+		return
+	}
+	cmd.PrintErrf("Wrote the live version of %s to %s\n", conflict.Path, remoteFile)
+}
+
+// remotePath returns the .remote.md file the live version of a page is
+// written to: next to the local file, or named after the page for stdin
+func remotePath(file, path string) string {
+	if file == "-" || file == "" {
+		return path + ".remote.md"
+	}
+	return strings.TrimSuffix(file, filepath.Ext(file)) + ".remote.md"
+}
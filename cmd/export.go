@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+
+	"telegraphcli/pkg/markdown"
+	"telegraphcli/pkg/render"
+	"telegraphcli/pkg/token"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export your Telegra.ph pages",
+	Long:  `Export pages for offline reading.`,
+}
+
+// exportHTMLCmd represents the export html command
+var exportHTMLCmd = &cobra.Command{
+	Use:   "html <dir> [paths...]",
+	Short: "Export pages to a static HTML site",
+	Args:  cobra.MinimumNArgs(1),
+	Long: `Export pages to a static HTML site that can be browsed without network access.
+All pages of the account are exported unless paths are given. Images hosted on
+telegra.ph are downloaded, links between exported pages are rewritten to the
+local files, and an index.html listing the pages is written.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancel()
+
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		dir := args[0]
+
+		pages, err := fetchExportPages(ctx, cmd, args[1:])
+		if err != nil {
+			cmd.PrintErrf("Failed to fetch pages: %v\n", err)
+			return
+		}
+
+		if err := os.MkdirAll(filepath.Join(dir, "images"), 0755); err != nil {
+			cmd.PrintErrf("Failed to create export directory: %v\n", err)
+			return
+		}
+
+		exported := map[string]bool{}
+		for _, page := range pages {
+			exported[page.Path] = true
+		}
+
+		images := &imageCache{dir: dir, files: map[string]string{}}
+		for _, page := range pages {
+			if verbose {
+				cmd.Println("Exporting", page.Path)
+			}
+
+			body := render.HTML(page.Content, render.Options{
+				URL: func(attr, value string) string {
+					return exportURL(ctx, cmd, images, exported, attr, value)
+				},
+			})
+			doc := render.Document(render.Page{
+				Title:      titleOf(page),
+				AuthorName: authorOf(page),
+				Body:       body,
+				Footer:     `<p class="tl_footer"><a href="index.html">← All pages</a> · <a href="` + html.EscapeString(page.URL.String()) + `">telegra.ph</a></p>` + "\n",
+			})
+
+			if err := os.WriteFile(filepath.Join(dir, page.Path+".html"), []byte(doc), 0644); err != nil {
+				cmd.PrintErrf("Failed to write %s: %v\n", page.Path, err)
+				return
+			}
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte(exportIndex(pages)), 0644); err != nil {
+			cmd.PrintErrf("Failed to write index: %v\n", err)
+			return
+		}
+
+		cmd.Printf("Exported %d pages and %d images to %s\n", len(pages), len(images.files), dir)
+	},
+}
+
+// fetchExportPages fetches the given pages with content, or every page of
+// the account when no paths are given
+func fetchExportPages(ctx context.Context, cmd *cobra.Command, paths []string) ([]*telegraph.Page, error) {
+	if len(paths) == 0 {
+		accessToken, err := token.GetToken()
+		if err != nil {
+			return nil, err
+		}
+		list, err := fetchAllPages(ctx, accessToken)
+		if err != nil {
+			return nil, err
+		}
+		for _, page := range list {
+			paths = append(paths, page.Path)
+		}
+	}
+
+	pages := make([]*telegraph.Page, 0, len(paths))
+	for _, p := range paths {
+		page, err := fetchPage(ctx, p, true)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", p, err)
+		}
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
+
+// exportURL rewrites links between exported pages to local files, downloads
+// images hosted on telegra.ph and makes other relative URLs absolute
+func exportURL(ctx context.Context, cmd *cobra.Command, images *imageCache, exported map[string]bool, attr, value string) string {
+	u, err := url.Parse(value)
+	if err != nil || !isTelegraphURL(u) || (u.Host == "" && u.Path == "") {
+		return value
+	}
+
+	if attr == "href" {
+		pagePath := strings.TrimPrefix(u.Path, "/")
+		if exported[pagePath] {
+			local := pagePath + ".html"
+			if u.Fragment != "" {
+				local += "#" + u.Fragment
+			}
+			return local
+		}
+	} else if strings.HasPrefix(u.Path, "/file/") {
+		local, err := images.fetch(ctx, absoluteTelegraphURL(u))
+		if err == nil {
+			return local
+		}
+		cmd.PrintErrf("Failed to download %s: %v\n", value, err)
+	}
+
+	return absoluteTelegraphURL(u)
+}
+
+// isTelegraphURL reports whether u is relative or points at telegra.ph
+func isTelegraphURL(u *url.URL) bool {
+	switch u.Host {
+	case "", "telegra.ph", "graph.org":
+		return u.Scheme == "" || u.Scheme == "http" || u.Scheme == "https"
+	}
+	return false
+}
+
+// absoluteTelegraphURL resolves a URL against telegra.ph
+func absoluteTelegraphURL(u *url.URL) string {
+	base, _ := url.Parse(telegraphURL)
+	return base.ResolveReference(u).String()
+}
+
+// imageCache downloads each image once
+type imageCache struct {
+	dir   string
+	files map[string]string
+}
+
+// fetch downloads an image into the images directory and returns its path
+// relative to the export directory
+func (c *imageCache) fetch(ctx context.Context, src string) (string, error) {
+	if local, ok := c.files[src]; ok {
+		return local, nil
+	}
+
+	local := "images/" + path.Base(strings.SplitN(src, "?", 2)[0])
+	err := retry(func() error {
+		return downloadFile(ctx, src, filepath.Join(c.dir, filepath.FromSlash(local)))
+	}, 3)
+	if err != nil {
+		return "", err
+	}
+
+	c.files[src] = local
+	return local, nil
+}
+
+// downloadFile fetches a URL into a local file
+func downloadFile(ctx context.Context, src, dest string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := newAPIClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// exportIndex renders the index page listing all exported pages
+func exportIndex(pages []*telegraph.Page) string {
+	items := make([]telegraph.Node, 0, len(pages))
+	for _, page := range pages {
+		items = append(items, markdown.Element(atom.Li, markdown.Link(page.Path+".html", telegraph.Node{Text: titleOf(page)})))
+	}
+	list := markdown.Element(atom.Ul, items...)
+
+	return render.Document(render.Page{
+		Title: "Pages",
+		Body:  `<div class="tl_index">` + render.HTML([]telegraph.Node{list}, render.Options{}) + `</div>`,
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportHTMLCmd)
+}
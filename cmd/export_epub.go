@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+
+	"telegraphcli/pkg/epub"
+	"telegraphcli/pkg/markdown"
+	"telegraphcli/pkg/render"
+	"telegraphcli/pkg/token"
+)
+
+// exportEPUBCmd represents the export epub command
+var exportEPUBCmd = &cobra.Command{
+	Use:   "epub [paths...]",
+	Short: "Export pages to an EPUB e-book",
+	Long: `Export pages to an EPUB 3 e-book with one chapter per page, in the order given.
+Use --all to include every page of the account. Images are embedded in the
+book, links between included pages point at the chapters, and the author is
+taken from the account info. The book is validated after it is written.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancel()
+
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		out, _ := cmd.Flags().GetString("out")
+		all, _ := cmd.Flags().GetBool("all")
+		title, _ := cmd.Flags().GetString("title")
+		lang, _ := cmd.Flags().GetString("lang")
+
+		if all == (len(args) > 0) {
+			cmd.PrintErrf("Give either page paths or --all\n")
+			return
+		}
+
+		accessToken, err := token.GetToken()
+		if err != nil {
+			cmd.PrintErrf("Failed to get token: %v\n", err)
+			return
+		}
+		account, err := fetchAccount(ctx, accessToken, telegraph.FieldShortName, telegraph.FieldAuthorName)
+		if err != nil {
+			cmd.PrintErrf("Failed to get account info: %v\n", err)
+			return
+		}
+		if title == "" {
+			title = account.ShortName.String()
+		}
+
+		pages, err := fetchExportPages(ctx, cmd, args)
+		if err != nil {
+			cmd.PrintErrf("Failed to fetch pages: %v\n", err)
+			return
+		}
+
+		chapters := map[string]string{}
+		for i, page := range pages {
+			chapters[page.Path] = fmt.Sprintf("chapter-%d.xhtml", i+1)
+		}
+
+		book := epub.Book{
+			Title:      title,
+			Author:     account.AuthorName.String(),
+			Language:   lang,
+			Identifier: epub.NewIdentifier(),
+			Modified:   time.Now(),
+		}
+		images := &epubImages{files: map[string]string{}}
+		for _, page := range pages {
+			if verbose {
+				cmd.Println("Adding", page.Path)
+			}
+
+			nodes := images.nodes(ctx, cmd, page.Content)
+			body := render.HTML(nodes, render.Options{
+				XHTML: true,
+				URL: func(attr, value string) string {
+					if attr != "href" {
+						return value
+					}
+					return epubLink(chapters, value)
+				},
+			})
+			book.Chapters = append(book.Chapters, epub.Chapter{
+				Title:    titleOf(page),
+				FileName: chapters[page.Path],
+				Body:     body,
+			})
+		}
+		book.Images = images.images
+
+		var buf bytes.Buffer
+		if err := epub.Write(&buf, book); err != nil {
+			cmd.PrintErrf("Failed to build EPUB: %v\n", err)
+			return
+		}
+		if err := epub.Validate(bytes.NewReader(buf.Bytes()), int64(buf.Len())); err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+		if err := os.WriteFile(out, buf.Bytes(), 0644); err != nil {
+			cmd.PrintErrf("Failed to write %s: %v\n", out, err)
+			return
+		}
+
+		cmd.Printf("Wrote %s with %d chapters and %d images\n", out, len(book.Chapters), len(book.Images))
+	},
+}
+
+// exportEPUBCheckCmd represents the export epubcheck command
+var exportEPUBCheckCmd = &cobra.Command{
+	Use:   "epubcheck <file>",
+	Short: "Check the structure of an EPUB file",
+	Args:  cobra.ExactArgs(1),
+	Long: `Check the structure of an EPUB 3 file: the container, the package metadata,
+the manifest and spine, the navigation document and that all XHTML documents
+are well-formed, with unique ids and links pointing inside the book.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		f, err := os.Open(args[0])
+		if err != nil {
+			cmd.PrintErrf("Failed to open %s: %v\n", args[0], err)
+			return
+		}
+		defer f.Close()
+
+		info, err := f.Stat()
+		if err != nil {
+			cmd.PrintErrf("Failed to open %s: %v\n", args[0], err)
+			return
+		}
+
+		if err := epub.Validate(f, info.Size()); err != nil {
+			cmd.PrintErrf("%s: %v\n", args[0], err)
+			os.Exit(1)
+		}
+		cmd.Printf("%s: OK\n", args[0])
+	},
+}
+
+// epubLink points links to included pages at their chapter and makes other
+// relative links absolute
+func epubLink(chapters map[string]string, value string) string {
+	u, err := url.Parse(value)
+	if err != nil || !isTelegraphURL(u) || (u.Host == "" && u.Path == "") {
+		return value
+	}
+
+	if chapter, ok := chapters[strings.TrimPrefix(u.Path, "/")]; ok {
+		if u.Fragment != "" {
+			chapter += "#" + u.Fragment
+		}
+		return chapter
+	}
+	return absoluteTelegraphURL(u)
+}
+
+// epubImages downloads the images of the book, each once
+type epubImages struct {
+	files  map[string]string
+	images []epub.Image
+}
+
+// nodes returns a copy of the nodes with images pointing at embedded files.
+// Embeds, videos and images that cannot be embedded become links.
+func (e *epubImages) nodes(ctx context.Context, cmd *cobra.Command, nodes []telegraph.Node) []telegraph.Node {
+	out := make([]telegraph.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if n.Element == nil {
+			out = append(out, n)
+			continue
+		}
+
+		switch n.Element.Tag.Atom() {
+		case atom.Img, atom.Iframe, atom.Video:
+			src := n.Element.Attrs["src"]
+			if u, err := url.Parse(src); err == nil && isTelegraphURL(u) {
+				src = absoluteTelegraphURL(u)
+			}
+			if n.Element.Tag.Atom() == atom.Img {
+				local, err := e.fetch(ctx, src)
+				if err == nil {
+					img := *n.Element
+					img.Attrs = map[string]string{"src": local}
+					out = append(out, telegraph.Node{Element: &img})
+					continue
+				}
+				cmd.PrintErrf("Failed to embed %s: %v\n", src, err)
+			}
+			out = append(out, markdown.Link(src, telegraph.Node{Text: src}))
+
+		default:
+			el := *n.Element
+			el.Children = e.nodes(ctx, cmd, el.Children)
+			out = append(out, telegraph.Node{Element: &el})
+		}
+	}
+	return out
+}
+
+// fetch downloads an image and returns its path inside the book
+func (e *epubImages) fetch(ctx context.Context, src string) (string, error) {
+	if local, ok := e.files[src]; ok {
+		return local, nil
+	}
+
+	var data []byte
+	err := retry(func() error {
+		var e error
+		data, e = downloadBytes(ctx, src)
+		return e
+	}, 3)
+	if err != nil {
+		return "", err
+	}
+
+	mediaType := http.DetectContentType(data)
+	ext, ok := epub.MediaTypes[mediaType]
+	if !ok {
+		return "", fmt.Errorf("unsupported image type %s", mediaType)
+	}
+
+	local := fmt.Sprintf("images/%d%s", len(e.images)+1, ext)
+	e.images = append(e.images, epub.Image{FileName: local, MediaType: mediaType, Data: data})
+	e.files[src] = local
+	return local, nil
+}
+
+// downloadBytes fetches a URL into memory
+func downloadBytes(ctx context.Context, src string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := newAPIClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func init() {
+	exportCmd.AddCommand(exportEPUBCmd)
+	exportCmd.AddCommand(exportEPUBCheckCmd)
+
+	exportEPUBCmd.Flags().StringP("out", "o", "book.epub", "Output file")
+	exportEPUBCmd.Flags().Bool("all", false, "Include every page of the account")
+	exportEPUBCmd.Flags().String("title", "", "Book title (defaults to the account short name)")
+	exportEPUBCmd.Flags().String("lang", "en", "Book language")
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+
+	"telegraphcli/pkg/feed"
+	"telegraphcli/pkg/render"
+	"telegraphcli/pkg/token"
+)
+
+// feedCmd represents the feed command
+var feedCmd = &cobra.Command{
+	Use:   "feed",
+	Short: "Build an Atom, RSS or JSON feed of your pages",
+	Long: `Build a feed of the pages of the account in Atom, RSS or JSON Feed format.
+Telegraph does not report when pages were published, so the date a page first
+appears in the page list is recorded in ~/.telegraphcl/feed.json and used as
+the item date. Run the command regularly to keep the dates close to the real
+publication dates.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+
+		format, _ := cmd.Flags().GetString("format")
+		out, _ := cmd.Flags().GetString("out")
+		title, _ := cmd.Flags().GetString("title")
+		description, _ := cmd.Flags().GetString("description")
+		link, _ := cmd.Flags().GetString("link")
+		feedURL, _ := cmd.Flags().GetString("feed-url")
+		withContent, _ := cmd.Flags().GetBool("content")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		accessToken, err := token.GetToken()
+		if err != nil {
+			cmd.PrintErrf("Failed to get token: %v\n", err)
+			return
+		}
+
+		account, err := fetchAccount(ctx, accessToken, telegraph.FieldShortName, telegraph.FieldAuthorName, telegraph.FieldAuthorURL)
+		if err != nil {
+			cmd.PrintErrf("Failed to get account info: %v\n", err)
+			return
+		}
+
+		pages, err := fetchAllPages(ctx, accessToken)
+		if err != nil {
+			cmd.PrintErrf("Failed to get page list: %v\n", err)
+			return
+		}
+
+		dates, err := feed.LoadDates()
+		if err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+
+		f := feed.Feed{
+			Title:       title,
+			Description: description,
+			Link:        link,
+			FeedURL:     feedURL,
+			Author:      account.AuthorName.String(),
+		}
+		if account.AuthorURL.URL != nil {
+			f.AuthorURL = account.AuthorURL.String()
+		}
+		if f.Title == "" {
+			f.Title = account.ShortName.String()
+		}
+		if f.Link == "" {
+			f.Link = f.AuthorURL
+		}
+		if f.Link == "" {
+			f.Link = telegraphURL
+		}
+
+		// The page list is ordered newest first; pages seen for the first
+		// time in the same run get dates one second apart to keep that order
+		now := time.Now()
+		paths := map[string]string{}
+		for i := range pages {
+			page := &pages[i]
+			item := feed.Item{
+				Title:       titleOf(page),
+				URL:         page.URL.String(),
+				Description: page.Description,
+				Author:      authorOf(page),
+				Published:   dates.Seen(page.Path, now.Add(-time.Duration(i)*time.Second)),
+			}
+			if page.ImageURL != nil {
+				item.Image = page.ImageURL.String()
+			}
+			f.Items = append(f.Items, item)
+			paths[item.URL] = page.Path
+		}
+
+		if err := dates.Save(); err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+
+		sort.SliceStable(f.Items, func(i, j int) bool {
+			return f.Items[i].Published.After(f.Items[j].Published)
+		})
+		if limit > 0 && len(f.Items) > limit {
+			f.Items = f.Items[:limit]
+		}
+
+		if withContent {
+			for i := range f.Items {
+				path := paths[f.Items[i].URL]
+				page, err := fetchPage(ctx, path, true)
+				if err != nil {
+					cmd.PrintErrf("Failed to get page %s: %v\n", path, err)
+					return
+				}
+				f.Items[i].Content = render.HTML(page.Content, render.Options{URL: absoluteURL})
+			}
+		}
+
+		data, err := feed.Encode(format, f)
+		if err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+
+		if out == "" || out == "-" {
+			cmd.OutOrStdout().Write(data)
+			return
+		}
+		if err := os.WriteFile(out, data, 0644); err != nil {
+			cmd.PrintErrf("Failed to write %s: %v\n", out, err)
+			return
+		}
+		cmd.PrintErrf("Wrote %d items to %s\n", len(f.Items), out)
+	},
+}
+
+// absoluteURL makes relative telegra.ph URLs absolute so that rendered
+// content works outside telegra.ph
+func absoluteURL(attr, value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.Host != "" || u.Path == "" {
+		return value
+	}
+	return absoluteTelegraphURL(u)
+}
+
+func init() {
+	rootCmd.AddCommand(feedCmd)
+
+	feedCmd.Flags().StringP("format", "f", "atom", "Feed format: "+strings.Join(feed.Formats, ", "))
+	feedCmd.Flags().StringP("out", "o", "", "Output file (defaults to stdout)")
+	feedCmd.Flags().String("title", "", "Feed title (defaults to the account short name)")
+	feedCmd.Flags().String("description", "", "Feed description")
+	feedCmd.Flags().String("link", "", "Home page of the feed (defaults to the author URL)")
+	feedCmd.Flags().String("feed-url", "", "URL the feed is published at")
+	feedCmd.Flags().Bool("content", false, "Include the full page content in each item")
+	feedCmd.Flags().Int("limit", 50, "Maximum number of items; 0 includes every page")
+}
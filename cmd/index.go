@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+
+	"telegraphcli/pkg/markdown"
+	"telegraphcli/pkg/state"
+	"telegraphcli/pkg/token"
+)
+
+// indexCmd represents the index command
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Maintain generated table of contents pages",
+	Long:  `Maintain Telegraph pages that list your other pages.`,
+}
+
+// indexBuildCmd represents the index build command
+var indexBuildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Create or update a page listing your pages",
+	Long: `Create or update a Telegraph page that links to every page of the account,
+or to the pages matching --match or --tag. Tags come from the front matter of
+files published with sync. Pages can be grouped by tag, category, author or
+first letter and sorted by title, views or age. Pages generated by index
+build and tags publish are not listed.
+
+The path of the generated page is remembered under the index --name, so later
+runs edit the same page instead of creating a new one.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+
+		name, _ := cmd.Flags().GetString("name")
+		title, _ := cmd.Flags().GetString("title")
+		intro, _ := cmd.Flags().GetString("intro")
+		match, _ := cmd.Flags().GetString("match")
+		tags, _ := cmd.Flags().GetStringSlice("tag")
+		groupBy, _ := cmd.Flags().GetString("group-by")
+		sortBy, _ := cmd.Flags().GetString("sort")
+		descriptions, _ := cmd.Flags().GetBool("descriptions")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		var pattern *regexp.Regexp
+		if match != "" {
+			var err error
+			pattern, err = regexp.Compile("(?i)" + match)
+			if err != nil {
+				cmd.PrintErrf("Invalid --match pattern: %v\n", err)
+				return
+			}
+		}
+
+		accessToken, err := token.GetToken()
+		if err != nil {
+			cmd.PrintErrf("Failed to get token: %v\n", err)
+			return
+		}
+
+		st, err := state.Load()
+		if err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+		index, hasIndex := st.Index(name)
+
+		pages, err := fetchAllPages(ctx, accessToken)
+		if err != nil {
+			cmd.PrintErrf("Failed to get page list: %v\n", err)
+			return
+		}
+
+		entries := entriesByPath(st)
+		var selected []indexPage
+		for i := range pages {
+			page := &pages[i]
+			if st.IsGenerated(page.Path) {
+				// Index and tag pages, including the one being built
+				continue
+			}
+			if pattern != nil && !pattern.MatchString(titleOf(page)) {
+				continue
+			}
+			entry := entries[page.Path]
+			if len(tags) > 0 && !hasAnyTag(entry.Tags, tags) {
+				continue
+			}
+			selected = append(selected, indexPage{Page: page, Order: i, Tags: entry.Tags, Category: entry.Category})
+		}
+
+		if err := sortIndexPages(selected, sortBy); err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+		groups, err := groupIndexPages(selected, groupBy)
+		if err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+
+		if dryRun {
+			for _, g := range groups {
+				if g.Name != "" {
+					cmd.Printf("%s\n", g.Name)
+				}
+				for _, p := range g.Pages {
+					cmd.Printf("  %s (%s)\n", titleOf(p.Page), p.Page.Path)
+				}
+			}
+			return
+		}
+
+		nodes := indexNodes(intro, groups, descriptions)
+		page, err := savePage(ctx, accessToken, index.Path, title, nodes)
+		if err != nil {
+			cmd.PrintErrf("Failed to publish index: %v\n", err)
+			return
+		}
+
+		st.SetIndex(name, state.Entry{Path: page.Path, URL: page.URL.String(), Title: title})
+		if err := st.Save(); err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+
+		if hasIndex {
+			cmd.Printf("Updated index %s with %d pages: %s\n", name, len(selected), page.URL.String())
+		} else {
+			cmd.Printf("Created index %s with %d pages: %s\n", name, len(selected), page.URL.String())
+		}
+	},
+}
+
+// indexPage is a page listed in an index
+type indexPage struct {
+	Page *telegraph.Page
+	// Order is the position of the page in the page list, newest first
+	Order    int
+	Tags     []string
+	Category string
+}
+
+// indexGroup is a titled section of an index
+type indexGroup struct {
+	Name  string
+	Pages []indexPage
+}
+
+// entriesByPath returns the sync state entries by page path
+func entriesByPath(st *state.State) map[string]state.Entry {
+	entries := map[string]state.Entry{}
+	for _, entry := range st.Files {
+		entries[entry.Path] = entry
+	}
+	return entries
+}
+
+// hasAnyTag reports whether tags contains any of want, ignoring case
+func hasAnyTag(tags, want []string) bool {
+	for _, t := range tags {
+		for _, w := range want {
+			if strings.EqualFold(t, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sortIndexPages orders pages by title, views, newest or oldest first
+func sortIndexPages(pages []indexPage, by string) error {
+	var less func(a, b indexPage) bool
+	switch by {
+	case "title":
+		less = func(a, b indexPage) bool {
+			return strings.ToLower(titleOf(a.Page)) < strings.ToLower(titleOf(b.Page))
+		}
+	case "views":
+		less = func(a, b indexPage) bool { return a.Page.Views > b.Page.Views }
+	case "newest":
+		less = func(a, b indexPage) bool { return a.Order < b.Order }
+	case "oldest":
+		less = func(a, b indexPage) bool { return a.Order > b.Order }
+	default:
+		return fmt.Errorf("unknown sort order %q, use title, views, newest or oldest", by)
+	}
+
+	sort.SliceStable(pages, func(i, j int) bool { return less(pages[i], pages[j]) })
+	return nil
+}
+
+// groupIndexPages splits sorted pages into groups named by tag, category,
+// author or first letter. Groups are ordered by name, with pages that have no group
+// last. A page with several tags is listed under each of them.
+func groupIndexPages(pages []indexPage, by string) ([]indexGroup, error) {
+	var keys func(p indexPage) []string
+	switch by {
+	case "", "none":
+		return []indexGroup{{Pages: pages}}, nil
+	case "tag":
+		keys = func(p indexPage) []string { return p.Tags }
+	case "category":
+		keys = func(p indexPage) []string {
+			if p.Category != "" {
+				return []string{p.Category}
+			}
+			return nil
+		}
+	case "author":
+		keys = func(p indexPage) []string {
+			if author := authorOf(p.Page); author != "" {
+				return []string{author}
+			}
+			return nil
+		}
+	case "letter":
+		keys = func(p indexPage) []string {
+			r, _ := utf8.DecodeRuneInString(titleOf(p.Page))
+			if !unicode.IsLetter(r) {
+				return []string{"#"}
+			}
+			return []string{string(unicode.ToUpper(r))}
+		}
+	default:
+		return nil, fmt.Errorf("unknown grouping %q, use none, tag, category, author or letter", by)
+	}
+
+	byName := map[string]*indexGroup{}
+	var other indexGroup
+	for _, p := range pages {
+		names := keys(p)
+		if len(names) == 0 {
+			other.Pages = append(other.Pages, p)
+			continue
+		}
+		for _, name := range names {
+			g, ok := byName[name]
+			if !ok {
+				g = &indexGroup{Name: name}
+				byName[name] = g
+			}
+			g.Pages = append(g.Pages, p)
+		}
+	}
+
+	groups := make([]indexGroup, 0, len(byName)+1)
+	for _, g := range byName {
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return strings.ToLower(groups[i].Name) < strings.ToLower(groups[j].Name)
+	})
+	if len(other.Pages) > 0 {
+		other.Name = "Other"
+		groups = append(groups, other)
+	}
+	return groups, nil
+}
+
+// indexNodes renders the index page content
+func indexNodes(intro string, groups []indexGroup, descriptions bool) []telegraph.Node {
+	var nodes []telegraph.Node
+	if intro != "" {
+		nodes = append(nodes, markdown.Element(atom.P, telegraph.Node{Text: intro}))
+	}
+
+	for _, g := range groups {
+		if g.Name != "" {
+			nodes = append(nodes, markdown.Element(atom.H4, telegraph.Node{Text: g.Name}))
+		}
+		items := make([]telegraph.Node, 0, len(g.Pages))
+		for _, p := range g.Pages {
+			item := []telegraph.Node{markdown.Link(p.Page.URL.String(), telegraph.Node{Text: titleOf(p.Page)})}
+			if descriptions && p.Page.Description != "" {
+				item = append(item, telegraph.Node{Text: " — " + p.Page.Description})
+			}
+			items = append(items, markdown.Element(atom.Li, item...))
+		}
+		nodes = append(nodes, markdown.Element(atom.Ul, items...))
+	}
+
+	if len(nodes) == 0 {
+		nodes = append(nodes, markdown.Element(atom.P, telegraph.Node{Text: "No pages yet."}))
+	}
+	return nodes
+}
+
+func init() {
+	rootCmd.AddCommand(indexCmd)
+	indexCmd.AddCommand(indexBuildCmd)
+
+	indexBuildCmd.Flags().String("name", "default", "Name under which the index page is remembered")
+	indexBuildCmd.Flags().String("title", "Contents", "Title of the index page")
+	indexBuildCmd.Flags().String("intro", "", "Paragraph shown above the list")
+	indexBuildCmd.Flags().String("match", "", "Only list pages whose title matches this regular expression")
+	indexBuildCmd.Flags().StringSlice("tag", nil, "Only list pages with this front matter tag (repeatable)")
+	indexBuildCmd.Flags().String("group-by", "none", "Group pages by none, tag, category, author or letter")
+	indexBuildCmd.Flags().String("sort", "title", "Sort pages by title, views, newest or oldest")
+	indexBuildCmd.Flags().Bool("descriptions", false, "Show page descriptions after the links")
+	indexBuildCmd.Flags().Bool("dry-run", false, "Print the index instead of publishing it")
+}
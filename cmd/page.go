@@ -2,17 +2,23 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"net/http"
 	"net/url" // Added import
+	"os"
+	"strings"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/spf13/cobra"
 	telegraph "source.toby3d.me/toby3d/telegraph/v2"
-	"golang.org/x/net/html/atom"
 
 	"telegraphcli/pkg/markdown"
+	"telegraphcli/pkg/state"
 	"telegraphcli/pkg/token"
+	"telegraphcli/pkg/upload"
+	"telegraphcli/pkg/views"
 )
 
 // pageCmd represents the page command
@@ -28,7 +34,10 @@ var pageCreateCmd = &cobra.Command{
 	Use:   "create <markdown-path> <title>",
 	Short: "Create Page from a Markdown file",
 	Args:  cobra.ExactArgs(2),
-	Long:  `Create a new Telegra.ph page from a Markdown file.`,
+	Long: `Create a new Telegra.ph page from a Markdown file.
+Use - as the markdown path to read from stdin. HTML, AsciiDoc, Org-mode,
+reStructuredText and Jupyter notebook files are converted as well; the format
+is picked from the file extension or set with --format.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second) // Increased timeout for multiple API calls
 		defer cancel()
@@ -106,7 +115,7 @@ var pageCreateCmd = &cobra.Command{
 		}
 
 		// Parse markdown file
-		nodes, err := markdown.Parse(markdownPath)
+		nodes, err := parseContentArg(cmd, markdownPath)
 		if err != nil {
 			cmd.PrintErrf("Failed to parse markdown: %v\n", err)
 			return
@@ -154,11 +163,12 @@ var pageCreateCmd = &cobra.Command{
 		}
 		
 		createPage := telegraph.CreatePage{
-			AccessToken: accessToken,
-			Title:       *pageTitle,
-			Content:     nodes,
-			AuthorName:  telegraphAuthorName, // Use pointer to telegraph.AuthorName
-			AuthorURL:   telegraphAuthorURL,  // Use pointer to telegraph.URL
+			AccessToken:   accessToken,
+			Title:         *pageTitle,
+			Content:       nodes,
+			AuthorName:    telegraphAuthorName, // Use pointer to telegraph.AuthorName
+			AuthorURL:     telegraphAuthorURL,  // Use pointer to telegraph.URL
+			ReturnContent: true,
 		}
 		
 		// Create a request using our custom HTTP client with user agent
@@ -190,6 +200,16 @@ var pageCreateCmd = &cobra.Command{
 			return
 		}
 
+		// Record the content hash so later edits can detect conflicts
+		st, err := state.Load()
+		if err == nil {
+			recordHash(st, page)
+			err = st.Save()
+		}
+		if err != nil {
+			cmd.PrintErrf("Failed to record content hash: %v\n", err)
+		}
+
 		cmd.Println("Page created successfully!")
 		cmd.Println("Title:", page.Title)
 		cmd.Println("URL:", page.URL)
@@ -201,6 +221,11 @@ var pageCreateCmd = &cobra.Command{
 var pageListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List your Telegra.ph pages",
+	Long: `List the pages of your account, --limit at a time from --offset, or every
+page with --all. Pages can be filtered by title, author, views and the tags
+and category of their synced file, sorted, and written as text, JSON or CSV.
+Filters apply to the pages fetched, so combine them with --all to search the
+whole account; --tag and --category always look through every page.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx := context.Background()
 		// client := http.DefaultClient // Not used directly anymore
@@ -214,52 +239,134 @@ var pageListCmd = &cobra.Command{
 		// Get page list
 		limit, _ := cmd.Flags().GetInt("limit")
 		offset, _ := cmd.Flags().GetInt("offset")
+		all, _ := cmd.Flags().GetBool("all")
+		format, _ := cmd.Flags().GetString("format")
+		sortBy, _ := cmd.Flags().GetString("sort")
+		reverse, _ := cmd.Flags().GetBool("reverse")
 
-		getPageList := telegraph.GetPageList{
-			AccessToken: accessToken,
-			Limit:       uint16(limit),
-			Offset:      uint(offset),
+		filter, err := newPageFilter(cmd)
+		if err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
 		}
-		
-		var pageList *telegraph.PageList
-		err = retry(func() error {
-			clientWithHeaders := &http.Client{
-				Timeout: httpClient.Timeout,
-				Transport: &customTransport{
-					base:      httpClient.Transport,
-					userAgent: userAgent,
-				},
+		// Tags and categories are only known for synced files, so look
+		// through every page
+		if filter.NeedsState() {
+			all = true
+		}
+
+		showViews := sortBy == "views" || cmd.Flags().Changed("min-views") || cmd.Flags().Changed("max-views")
+		out, err := newPageListWriter(cmd.OutOrStdout(), format, showViews, filter.Active())
+		if err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+		// Check --sort before fetching anything
+		if err := sortPages(nil, sortBy, false); err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+
+		var pages []telegraph.Page
+		var total uint
+		if all {
+			// Without sorting, text and CSV are written as each batch arrives
+			stream := sortBy == "" && !reverse && format != "json"
+			begun := false
+			err = forEachPage(ctx, accessToken, func(batch []telegraph.Page, count uint) error {
+				if stream && !begun {
+					out.Begin(count)
+					begun = true
+				}
+				total = count
+				for i := range batch {
+					if !filter.Match(&batch[i]) {
+						continue
+					}
+					if stream {
+						out.Page(&batch[i])
+					} else {
+						pages = append(pages, batch[i])
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				cmd.PrintErrf("Failed to get page list after retries: %v\n", err)
+				return
 			}
-			var e error
-			pageList, e = getPageList.Do(ctx, clientWithHeaders)
-			if e != nil {
-				if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
-					cmd.Printf("Request failed during page list: %v\\n", e)
+			if stream {
+				if !begun {
+					out.Begin(total)
 				}
+				if err := out.End(); err != nil {
+					cmd.PrintErrf("Failed to write page list: %v\n", err)
+				}
+				return
+			}
+		} else {
+			getPageList := telegraph.GetPageList{
+				AccessToken: accessToken,
+				Limit:       uint16(limit),
+				Offset:      uint(offset),
 			}
-			return e
-		}, 3)
 
-		if err != nil {
-			cmd.PrintErrf("Failed to get page list after retries: %v\\n", err)
-			return
+			var pageList *telegraph.PageList
+			err = retry(func() error {
+				clientWithHeaders := &http.Client{
+					Timeout: httpClient.Timeout,
+					Transport: &customTransport{
+						base:      httpClient.Transport,
+						userAgent: userAgent,
+					},
+				}
+				var e error
+				pageList, e = getPageList.Do(ctx, clientWithHeaders)
+				if e != nil {
+					if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
+						cmd.Printf("Request failed during page list: %v\n", e)
+					}
+				}
+				return e
+			}, 3)
+
+			if err != nil {
+				cmd.PrintErrf("Failed to get page list after retries: %v\n", err)
+				return
+			}
+
+			total = pageList.TotalCount
+			for i := range pageList.Pages {
+				if filter.Match(&pageList.Pages[i]) {
+					pages = append(pages, pageList.Pages[i])
+				}
+			}
 		}
 
-		cmd.Printf("Total pages: %d\\n", pageList.TotalCount)
-		cmd.Println("Pages:")
-		for i, page := range pageList.Pages {
-			cmd.Printf("%d. %s (%s)\n", i+1, page.Title.String(), page.URL.String())
+		sortPages(pages, sortBy, reverse)
+		out.Begin(total)
+		for i := range pages {
+			out.Page(&pages[i])
+		}
+		if err := out.End(); err != nil {
+			cmd.PrintErrf("Failed to write page list: %v\n", err)
 		}
 	},
 }
 
 // pageGetCmd represents the page get command
 var pageGetCmd = &cobra.Command{
-	Use:   "get <path>",
+	Use:   "get <path>...",
 	Short: "Get page with Telegra.ph path",
-	Args:  cobra.ExactArgs(1),
-	Long:  `Get details of a page by its path.`,
+	Long: `Get details of a page by its path.
+Several pages can be given at once, or read with --from-file, from stdin (-)
+or selected with --list; they are fetched concurrently and summarized.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if isBulk(cmd, args) {
+			getPages(cmd, args)
+			return
+		}
+
 		ctx := context.Background()
 		// client := http.DefaultClient // Not used directly anymore
 
@@ -305,11 +412,31 @@ var pageGetCmd = &cobra.Command{
 
 // pageDeleteCmd represents the page delete command
 var pageDeleteCmd = &cobra.Command{
-	Use:   "delete <path>",
+	Use:   "delete <path>...",
 	Short: "Delete a Telegra.ph page by editing its content to be empty",
-	Args:  cobra.ExactArgs(1),
-	Long:  `Effectively deletes a Telegra.ph page by clearing its title, content, author name, and author URL.`,
+	Long: `Effectively deletes a Telegra.ph page by clearing its title, content, author name, and author URL.
+The page is shown and confirmation is asked first; use --yes to skip it. The
+previous content is kept in the trash and can be brought back with page restore.
+
+The page is replaced with a tombstone chosen with --mode:
+  minimal   the title "Deleted" and a "[deleted]" paragraph (default)
+  template  a Markdown template, see --template
+  moved     a notice linking to the page given with --moved-to
+
+Templates and --title can use {{.Title}}, {{.Path}}, {{.URL}}, {{.Author}},
+{{.Redirect}} and {{.Date}}. Defaults for all of these can be set in the
+delete section of ~/.telegraphcl/config.yaml.
+
+Several pages can be given at once, or read with --from-file, from stdin (-)
+or selected with --list. They are all fetched and listed before a single
+confirmation. Pages read from stdin need --yes, as the confirmation would be
+read from stdin as well.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if isBulk(cmd, args) {
+			deletePages(cmd, args)
+			return
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 		defer cancel()
 
@@ -326,55 +453,50 @@ var pageDeleteCmd = &cobra.Command{
 			cmd.Printf("Attempting to 'delete' page with path: %s\\n", path)
 		}
 
-		// Prepare new minimal title
-		deletedTitle, err := telegraph.NewTitle("Deleted") // Or use a single space if API allows and preferred
+		// Get the current version to show it and keep it in the history
+		currentPage, err := fetchPage(ctx, path, true)
 		if err != nil {
-			cmd.PrintErrf("Failed to create 'Deleted' title: %v\\n", err)
+			cmd.PrintErrf("Failed to get current page after retries: %v\n", err)
 			return
 		}
 
-		// Prepare minimal content to satisfy API requirements
-		pTag, _ := telegraph.NewTag(atom.P)
-		pElem := telegraph.NewNodeElement(pTag)
-		textNode := telegraph.Node{
-			Text: "[deleted]",
+		opts, err := deleteOptions(cmd)
+		if err != nil {
+			cmd.PrintErrf("Failed to load config: %v\n", err)
+			return
 		}
-		pElem.Children = append(pElem.Children, textNode)
-		deletedContent := []telegraph.Node{
-			telegraph.Node{
-				Element: pElem,
-			},
+		title, deletedContent, err := tombstone(opts, currentPage)
+		if err != nil {
+			cmd.PrintErrf("Failed to prepare tombstone: %v\n", err)
+			return
 		}
 
-		editPage := telegraph.EditPage{
-			AccessToken:   accessToken,
-			Path:          path,
-			Title:         *deletedTitle,
-			Content:       deletedContent, // Use minimal non-empty content
-			AuthorName:    nil,            // Clear author name
-			AuthorURL:     nil,            // Clear author URL
-			ReturnContent: false,
+		cmd.Printf("Page: %s (%s)\n", titleOf(currentPage), currentPage.URL.String())
+		if yes, _ := cmd.Flags().GetBool("yes"); !yes && !confirm(cmd, "Delete this page?") {
+			cmd.Println("Aborted.")
+			return
 		}
 
-		err = retry(func() error {
-			if verbose {
-				cmd.Println("Sending request to Telegraph API to 'delete' page...")
-			}
-			clientWithHeaders := &http.Client{
-				Timeout:   httpClient.Timeout,
-				Transport: &customTransport{base: httpClient.Transport, userAgent: userAgent},
-			}
-			_, e := editPage.Do(ctx, clientWithHeaders) // We don't need the returned page
-			if e != nil && verbose {
-				cmd.Printf("Request failed: %v\\n", e)
-			}
-			return e
-		}, 3)
+		if verbose {
+			cmd.Println("Sending request to Telegraph API to 'delete' page...")
+		}
+		deleted, err := deletePage(ctx, accessToken, currentPage, title, deletedContent)
+		if err != nil {
+			cmd.PrintErrf("Failed to 'delete' page at path '%s': %v\n", path, err)
+			return
+		}
 
+		// The tombstone is the content last published from here, so
+		// editing the page later is not a conflict
+		st, err := state.Load()
 		if err != nil {
-			cmd.PrintErrf("Failed to 'delete' page at path '%s' after retries: %v\\n", path, err)
+			cmd.PrintErrf("Failed to load sync state: %v\n", err)
 			return
 		}
+		recordHash(st, deleted)
+		if err := st.Save(); err != nil {
+			cmd.PrintErrf("Failed to record content hash: %v\n", err)
+		}
 
 		cmd.Printf("Page at path '%s' has been 'deleted' (content cleared).\\n", path)
 	},
@@ -382,11 +504,31 @@ var pageDeleteCmd = &cobra.Command{
 
 // pageEditCmd represents the page edit command
 var pageEditCmd = &cobra.Command{
-	Use:   "edit <path> <markdown-path>",
+	Use:   "edit <path> <markdown-path> | --title <title> <path>...",
 	Short: "Edit page with Telegra.ph path",
-	Args:  cobra.ExactArgs(2),
-	Long:  `Edit an existing Telegra.ph page with a Markdown file.`,
+	Long: `Edit an existing Telegra.ph page with a Markdown file.
+Use - as the markdown path to read from stdin. HTML, AsciiDoc, Org-mode,
+reStructuredText and Jupyter notebook files are converted as well; the format
+is picked from the file extension or set with --format.
+
+If the page was changed on telegra.ph since it was last published from here,
+the edit is refused and the differences are shown. Use --force to overwrite
+the page, or --write-remote to save the live version as a .remote.md file to
+merge with.
+
+Without a markdown path only the title is changed, on one page or on many
+pages given as arguments, with --from-file, from stdin (-) or with --list.
+The title may then use {{.Title}} and {{.Path}}, for example
+--title "{{.Title}} (archived)". With two arguments the second is always
+read as the markdown path; use --from-file to retitle exactly two pages.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		fromFile, _ := cmd.Flags().GetString("from-file")
+		list, _ := cmd.Flags().GetBool("list")
+		if fromFile != "" || list || len(args) != 2 {
+			retitlePages(cmd, args)
+			return
+		}
+
 		ctx := context.Background()
 		// client := http.DefaultClient // Not used directly anymore
 
@@ -401,16 +543,16 @@ var pageEditCmd = &cobra.Command{
 		}
 
 		// Parse markdown file
-		nodes, err := markdown.Parse(markdownPath)
+		nodes, err := parseContentArg(cmd, markdownPath)
 		if err != nil {
 			cmd.PrintErrf("Failed to parse markdown: %v\\n", err)
 			return
 		}
 
-		// Get current page to keep the title
+		// Get current page to keep the title and check for conflicting edits
 		getPage := telegraph.GetPage{
 			Path:          path,
-			ReturnContent: false,
+			ReturnContent: true,
 		}
 		var currentPage *telegraph.Page
 		err = retry(func() error {
@@ -434,6 +576,25 @@ var pageEditCmd = &cobra.Command{
 			return
 		}
 
+		st, err := state.Load()
+		if err != nil {
+			cmd.PrintErrf("Failed to load sync state: %v\n", err)
+			return
+		}
+
+		force, _ := cmd.Flags().GetBool("force")
+		if err := checkConflict(st, currentPage); err != nil && !force {
+			writeRemote, _ := cmd.Flags().GetBool("write-remote")
+			reportConflict(cmd, err.(*conflictError), markdownPath, nodes, writeRemote)
+			cmd.PrintErrf("Conflict: %v\n", err)
+			return
+		}
+
+		if err := snapshotPage(currentPage, "edit"); err != nil {
+			cmd.PrintErrf("Failed to snapshot page: %v\n", err)
+			return
+		}
+
 		// Check if a new title was provided
 		var pageTitle telegraph.Title
 		if currentPage.Title != nil {
@@ -452,10 +613,11 @@ var pageEditCmd = &cobra.Command{
 
 		// Edit page
 		editPage := telegraph.EditPage{
-			AccessToken: accessToken,
-			Path:        path,
-			Title:       pageTitle,
-			Content:     nodes,
+			AccessToken:   accessToken,
+			Path:          path,
+			Title:         pageTitle,
+			Content:       nodes,
+			ReturnContent: true,
 		}
 
 		var page *telegraph.Page
@@ -480,6 +642,11 @@ var pageEditCmd = &cobra.Command{
 			return
 		}
 
+		recordHash(st, page)
+		if err := st.Save(); err != nil {
+			cmd.PrintErrf("Failed to record content hash: %v\n", err)
+		}
+
 		cmd.Println("Page edited successfully!")
 		cmd.Println("Title:", page.Title)
 		cmd.Println("URL:", page.URL)
@@ -489,11 +656,36 @@ var pageEditCmd = &cobra.Command{
 
 // pageViewsCmd represents the page views command
 var pageViewsCmd = &cobra.Command{
-	Use:   "views <path>",
+	Use:   "views <path>...",
 	Short: "Count views on your Telegra.ph page",
-	Args:  cobra.ExactArgs(1),
-	Long:  `Get the count of views on a particular page.`,
+	Long: `Get the count of views on a particular page.
+Several pages can be given at once, or read with --from-file, from stdin (-)
+or selected with --list.
+
+With --from and --to (YYYY-MM-DD, UTC) the views of a page are counted per
+--granularity period and shown as a table, CSV, JSON, a bar chart or a
+sparkline. Several pages with a range, or --all-pages, rank the pages by
+their views over the range; --all-pages without a range ranks them by their
+total views.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		from, to, ranged, err := viewsRange(cmd)
+		if err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+		if allPages, _ := cmd.Flags().GetBool("all-pages"); allPages || (ranged && isBulk(cmd, args)) {
+			rankViews(cmd, args, from, to, ranged)
+			return
+		}
+		if ranged {
+			viewsSeries(cmd, args[0], from, to)
+			return
+		}
+		if isBulk(cmd, args) {
+			viewsPages(cmd, args)
+			return
+		}
+
 		ctx := context.Background()
 		// client := http.DefaultClient // Not used directly anymore
 
@@ -515,7 +707,7 @@ var pageViewsCmd = &cobra.Command{
 		}
 
 		var views *telegraph.PageViews // Corrected type to telegraph.PageViews
-		err := retry(func() error {
+		err = retry(func() error {
 			clientWithHeaders := &http.Client{
 				Timeout: httpClient.Timeout,
 				Transport: &customTransport{
@@ -542,6 +734,60 @@ var pageViewsCmd = &cobra.Command{
 	},
 }
 
+// parseContentArg parses the content file given on the command line.
+// A path of "-" reads from stdin, resolving relative paths against --base-dir.
+// The format is taken from --format, or else from the file extension.
+// Images embedded in the source are uploaded to Telegraph.
+func parseContentArg(cmd *cobra.Command, contentPath string) ([]telegraph.Node, error) {
+	return parseContentWith(cmd, contentPath, func(data []byte, contentType string) (string, error) {
+		return upload.Upload(cmd.Context(), newAPIClient(), embeddedImageName(contentType), data, contentType)
+	})
+}
+
+// embeddedImageName returns the file name embedded images are uploaded as
+func embeddedImageName(contentType string) string {
+	return "image." + strings.TrimPrefix(contentType, "image/")
+}
+
+// parseContentWith parses the content file like parseContentArg, uploading
+// embedded images with uploadImage
+func parseContentWith(cmd *cobra.Command, contentPath string, uploadImage func(data []byte, contentType string) (string, error)) ([]telegraph.Node, error) {
+	opts := markdown.Options{UploadImage: uploadImage}
+	opts.Notebook.HideInputs, _ = cmd.Flags().GetBool("hide-inputs")
+	opts.Notebook.HideOutputs, _ = cmd.Flags().GetBool("hide-outputs")
+	opts.Notebook.MaxOutputLines, _ = cmd.Flags().GetInt("max-output-lines")
+
+	return convertContent(cmd, contentPath, opts)
+}
+
+// convertContent converts a content file, or stdin for "-", with the format
+// from --format or the file extension
+func convertContent(cmd *cobra.Command, contentPath string, opts markdown.Options) ([]telegraph.Node, error) {
+	format, _ := cmd.Flags().GetString("format")
+	if format == "" {
+		format = markdown.FormatFromPath(contentPath)
+	}
+
+	var r io.Reader
+	if contentPath == "-" {
+		opts.BaseDir, _ = cmd.Flags().GetString("base-dir")
+		r = cmd.InOrStdin()
+	} else {
+		f, err := os.Open(contentPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", contentPath, err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	converter, err := markdown.Lookup(format)
+	if err != nil {
+		return nil, err
+	}
+	return converter.Convert(r, opts)
+}
+
 // retry attempts a function with retries using exponential backoff
 func retry(fn func() error, attempts int) error { 
 	bo := backoff.NewExponentialBackOff()
@@ -569,13 +815,44 @@ func init() {
 	// Add flags to commands
 	pageListCmd.Flags().IntP("limit", "l", 10, "Limit the number of pages returned")
 	pageListCmd.Flags().IntP("offset", "o", 0, "Offset in the list of pages")
+	pageListCmd.Flags().BoolP("all", "a", false, "List every page, fetching them in batches until the total count")
+	pageListCmd.Flags().StringP("format", "f", "text", "Output format: "+strings.Join(listFormats, ", "))
+	pageListCmd.Flags().String("sort", "", "Sort by title, views (most first) or path")
+	pageListCmd.Flags().BoolP("reverse", "r", false, "Reverse the order")
+	addFilterFlags(pageListCmd)
 	
 	pageEditCmd.Flags().StringP("title", "t", "", "New title for the page")
+	pageEditCmd.Flags().Bool("force", false, "Overwrite the page even if it was changed on telegra.ph")
+	pageEditCmd.Flags().Bool("write-remote", false, "On a conflict, write the live page to a .remote.md file next to the local file")
+
+	for _, c := range []*cobra.Command{pageCreateCmd, pageEditCmd} {
+		c.Flags().String("base-dir", "", "Directory to resolve relative image and link paths against when reading from stdin")
+		c.Flags().StringP("format", "f", "", "Input format: "+strings.Join(markdown.Formats(), ", ")+" (default: from file extension)")
+		c.Flags().Bool("hide-inputs", false, "Leave out the source of notebook code cells")
+		c.Flags().Bool("hide-outputs", false, "Leave out the outputs of notebook code cells")
+		c.Flags().Int("max-output-lines", 0, "Truncate notebook text outputs longer than this many lines (0 keeps all)")
+	}
 	
+	pageDeleteCmd.Flags().BoolP("yes", "y", false, "Delete without asking for confirmation")
+	for _, c := range []*cobra.Command{pageGetCmd, pageViewsCmd, pageDeleteCmd, pageEditCmd} {
+		addBulkFlags(c)
+	}
+	pageDeleteCmd.Flags().String("mode", "minimal", "Tombstone to leave behind: minimal, template or moved")
+	pageDeleteCmd.Flags().String("template", "", "Markdown template for the tombstone (implies --mode template)")
+	pageDeleteCmd.Flags().StringP("title", "t", "", "Title of the tombstone, may use template variables")
+	pageDeleteCmd.Flags().String("redirect", "", "URL available to templates as {{.Redirect}}")
+	pageDeleteCmd.Flags().String("moved-to", "", "Page path or URL the content moved to (implies --mode moved)")
+
 	pageViewsCmd.Flags().IntP("year", "y", 0, "Year to filter views")
 	pageViewsCmd.Flags().IntP("month", "m", 0, "Month to filter views")
 	pageViewsCmd.Flags().IntP("day", "d", 0, "Day to filter views")
 	pageViewsCmd.Flags().IntP("hour", "H", 0, "Hour to filter views")
+	pageViewsCmd.Flags().String("from", "", "First day of a range to count, YYYY-MM-DD (default: start of the month of --to)")
+	pageViewsCmd.Flags().String("to", "", "Last day of a range to count, YYYY-MM-DD (default: today)")
+	pageViewsCmd.Flags().StringP("granularity", "g", "day", "Period of each count in a range: "+strings.Join(views.Granularities, ", "))
+	pageViewsCmd.Flags().StringP("format", "f", "table", "Output for a range: "+strings.Join(viewsFormats, ", "))
+	pageViewsCmd.Flags().Bool("all-pages", false, "Rank every page of the account by views")
+	pageViewsCmd.Flags().Int("top", 0, "Only show this many pages when ranking (0 for all)")
 }
 
 // customTransport adds custom headers to HTTP requests
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/spf13/cobra"
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+
+	"telegraphcli/pkg/state"
+	"telegraphcli/pkg/token"
+)
+
+// getPages shows the title, author and views of many pages
+func getPages(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	paths, err := pagePaths(ctx, cmd, args)
+	if err != nil {
+		cmd.PrintErrf("%v\n", err)
+		return
+	}
+
+	failed := runBulk(ctx, cmd, "Getting", paths, func(ctx context.Context, path string) (string, error) {
+		page, err := fetchPage(ctx, path, false)
+		if err != nil {
+			return "", err
+		}
+		detail := fmt.Sprintf("%s, %d views", titleOf(page), page.Views)
+		if author := authorOf(page); author != "" {
+			detail += ", by " + author
+		}
+		return detail, nil
+	})
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// viewsPages shows the view counts of many pages
+func viewsPages(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	paths, err := pagePaths(ctx, cmd, args)
+	if err != nil {
+		cmd.PrintErrf("%v\n", err)
+		return
+	}
+
+	year, _ := cmd.Flags().GetInt("year")
+	month, _ := cmd.Flags().GetInt("month")
+	day, _ := cmd.Flags().GetInt("day")
+	hour, _ := cmd.Flags().GetInt("hour")
+
+	failed := runBulk(ctx, cmd, "Counting views", paths, func(ctx context.Context, path string) (string, error) {
+		getViews := telegraph.GetViews{
+			Path:  path,
+			Year:  uint16(year),
+			Month: uint8(month),
+			Day:   uint8(day),
+			Hour:  uint8(hour),
+		}
+		var views *telegraph.PageViews
+		err := retry(func() error {
+			var e error
+			views, e = getViews.Do(ctx, newAPIClient())
+			return e
+		}, 3)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d views", views.Views), nil
+	})
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// deletePages replaces many pages with tombstones. All pages are fetched
+// first so they can be listed before confirming.
+func deletePages(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	yes, _ := cmd.Flags().GetBool("yes")
+	if !yes && readsStdin(cmd, args) {
+		cmd.PrintErrf("Pages read from stdin cannot be confirmed, use --yes to delete them\n")
+		return
+	}
+
+	accessToken, err := token.GetToken()
+	if err != nil {
+		cmd.PrintErrf("Failed to get token: %v\n", err)
+		return
+	}
+
+	paths, err := pagePaths(ctx, cmd, args)
+	if err != nil {
+		cmd.PrintErrf("%v\n", err)
+		return
+	}
+
+	opts, err := deleteOptions(cmd)
+	if err != nil {
+		cmd.PrintErrf("Failed to load config: %v\n", err)
+		return
+	}
+
+	pages, errs := fetchPages(ctx, cmd, paths)
+	cmd.Printf("Pages to delete (%d):\n", len(pages))
+	for _, path := range paths {
+		if page, ok := pages[path]; ok {
+			cmd.Printf("  %s (%s)\n", titleOf(page), page.URL.String())
+		} else {
+			cmd.Printf("  %s: %v\n", path, errs[path])
+		}
+	}
+	if len(pages) == 0 {
+		os.Exit(1)
+	}
+	if !yes && !confirm(cmd, fmt.Sprintf("Delete %s?", pluralPages(len(pages)))) {
+		cmd.Println("Aborted.")
+		return
+	}
+
+	st, err := state.Load()
+	if err != nil {
+		cmd.PrintErrf("Failed to load sync state: %v\n", err)
+		return
+	}
+	var mu sync.Mutex
+
+	failed := runBulk(ctx, cmd, "Deleting", paths, func(ctx context.Context, path string) (string, error) {
+		page, ok := pages[path]
+		if !ok {
+			return "", errs[path]
+		}
+		title, nodes, err := tombstone(opts, page)
+		if err != nil {
+			return "", err
+		}
+		deleted, err := deletePage(ctx, accessToken, page, title, nodes)
+		if err != nil {
+			return "", err
+		}
+		mu.Lock()
+		recordHash(st, deleted)
+		mu.Unlock()
+		return "was " + titleOf(page), nil
+	})
+	if err := st.Save(); err != nil {
+		cmd.PrintErrf("Failed to record content hashes: %v\n", err)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// retitleData holds the variables available to the title given to
+// retitlePages
+type retitleData struct {
+	Title string
+	Path  string
+}
+
+// retitlePages changes the title of many pages, keeping their content and
+// author. The title may use {{.Title}} and {{.Path}}.
+func retitlePages(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	titleTemplate, _ := cmd.Flags().GetString("title")
+	if titleTemplate == "" {
+		cmd.PrintErrf("Editing several pages needs --title\n")
+		return
+	}
+	if _, err := executeTemplate("title", titleTemplate, retitleData{}); err != nil {
+		cmd.PrintErrf("%v\n", err)
+		return
+	}
+
+	accessToken, err := token.GetToken()
+	if err != nil {
+		cmd.PrintErrf("Failed to get token: %v\n", err)
+		return
+	}
+
+	paths, err := pagePaths(ctx, cmd, args)
+	if err != nil {
+		cmd.PrintErrf("%v\n", err)
+		return
+	}
+
+	failed := runBulk(ctx, cmd, "Editing", paths, func(ctx context.Context, path string) (string, error) {
+		page, err := fetchPage(ctx, path, true)
+		if err != nil {
+			return "", err
+		}
+		title, err := executeTemplate("title", titleTemplate, retitleData{Title: titleOf(page), Path: page.Path})
+		if err != nil {
+			return "", err
+		}
+		if err := snapshotPage(page, "edit"); err != nil {
+			return "", fmt.Errorf("failed to snapshot page: %v", err)
+		}
+
+		s := pageSnapshot(page)
+		s.Title = title
+		if _, err := restoreSnapshot(ctx, accessToken, path, s); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%q -> %q", titleOf(page), title), nil
+	})
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// fetchPages gets many pages with their content on the worker pool, showing
+// progress on a terminal. Pages that could not be fetched are returned in
+// the error map.
+func fetchPages(ctx context.Context, cmd *cobra.Command, paths []string) (map[string]*telegraph.Page, map[string]error) {
+	var mu sync.Mutex
+	pages := map[string]*telegraph.Page{}
+	results := runPool(ctx, cmd, "Fetching", paths, func(ctx context.Context, path string) (string, error) {
+		page, err := fetchPage(ctx, path, true)
+		if err != nil {
+			return "", err
+		}
+		mu.Lock()
+		pages[path] = page
+		mu.Unlock()
+		return "", nil
+	})
+
+	errs := map[string]error{}
+	for _, r := range results {
+		if r.Err != nil {
+			errs[r.Item] = r.Err
+		}
+	}
+	return pages, errs
+}
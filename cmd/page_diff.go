@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+
+	"telegraphcli/pkg/diff"
+	"telegraphcli/pkg/markdown"
+	"telegraphcli/pkg/render"
+)
+
+// pageDiffCmd represents the page diff command
+var pageDiffCmd = &cobra.Command{
+	Use:   "diff <path> <markdown-path>",
+	Short: "Show the changes a local file would make to a page",
+	Args:  cobra.ExactArgs(2),
+	Long: `Compare the live content of a page with a local file before editing it.
+Both sides are converted to the same normalized form, Markdown by default or
+an outline of the Telegraph nodes with --mode nodes, and printed as a unified
+diff. Use --word for a word-level diff.
+
+The exit status is 0 when the page and the file match, 1 when they differ
+and 2 when the comparison fails, so scripts can decide whether to publish.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		path, file := args[0], args[1]
+		mode, _ := cmd.Flags().GetString("mode")
+		opts := diff.Options{}
+		opts.Context, _ = cmd.Flags().GetInt("context")
+		opts.Words, _ = cmd.Flags().GetBool("word")
+		colorMode, _ := cmd.Flags().GetString("color")
+
+		var err error
+		opts.Color, err = useColor(colorMode)
+		if err != nil {
+			cmd.PrintErrf("%v\n", err)
+			os.Exit(2)
+		}
+
+		page, err := fetchPage(ctx, path, true)
+		if err != nil {
+			cmd.PrintErrf("Failed to get page %s: %v\n", path, err)
+			os.Exit(2)
+		}
+
+		nodes, err := convertContent(cmd, file, markdown.Options{})
+		if err != nil {
+			cmd.PrintErrf("Failed to parse %s: %v\n", file, err)
+			os.Exit(2)
+		}
+
+		remote, err := normalizeContent(page.Content, mode)
+		if err != nil {
+			cmd.PrintErrf("%v\n", err)
+			os.Exit(2)
+		}
+		local, _ := normalizeContent(nodes, mode)
+
+		out := diff.Unified(telegraphURL+path, file, diff.Lines(remote), diff.Lines(local), opts)
+		if out == "" {
+			return
+		}
+		cmd.Print(out)
+		os.Exit(1)
+	},
+}
+
+// normalizeContent renders nodes as Markdown or as a node outline so that
+// content from different sources can be compared
+func normalizeContent(nodes []telegraph.Node, mode string) (string, error) {
+	opts := render.Options{URL: relativeTelegraphURL}
+	switch mode {
+	case "markdown":
+		return render.Markdown(nodes, opts), nil
+	case "nodes":
+		return render.Canonical(nodes, opts), nil
+	}
+	return "", fmt.Errorf("unknown mode %q, use markdown or nodes", mode)
+}
+
+// relativeTelegraphURL strips the telegra.ph host from URLs, since Telegraph
+// stores uploaded files as relative paths
+func relativeTelegraphURL(attr, value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" || !isTelegraphURL(u) {
+		return value
+	}
+	u.Scheme = ""
+	u.Host = ""
+	return u.String()
+}
+
+// useColor resolves a --color flag of auto, always or never
+func useColor(mode string) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		info, err := os.Stdout.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0 && os.Getenv("NO_COLOR") == "", nil
+	}
+	return false, fmt.Errorf("unknown color mode %q, use auto, always or never", mode)
+}
+
+func init() {
+	pageCmd.AddCommand(pageDiffCmd)
+
+	pageDiffCmd.Flags().String("mode", "markdown", "Normalized form to compare: markdown or nodes")
+	pageDiffCmd.Flags().Bool("word", false, "Show a word-level diff")
+	pageDiffCmd.Flags().IntP("context", "U", 3, "Number of unchanged lines shown around changes")
+	pageDiffCmd.Flags().String("color", "auto", "Colour the diff: auto, always or never")
+	pageDiffCmd.Flags().StringP("format", "f", "", "Input format: "+strings.Join(markdown.Formats(), ", ")+" (default: from file extension)")
+	pageDiffCmd.Flags().String("base-dir", "", "Directory to resolve relative paths against when reading from stdin")
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+
+	"telegraphcli/pkg/history"
+	"telegraphcli/pkg/render"
+	"telegraphcli/pkg/state"
+	"telegraphcli/pkg/token"
+)
+
+// pageHistoryCmd represents the page history command
+var pageHistoryCmd = &cobra.Command{
+	Use:   "history <path>",
+	Short: "List the saved snapshots of a page",
+	Args:  cobra.ExactArgs(1),
+	Long: `List the snapshots saved before a page was edited, deleted or rolled back.
+Use --show to print the content of one snapshot as Markdown.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		path := args[0]
+
+		show, _ := cmd.Flags().GetInt("show")
+		if show > 0 {
+			snapshot, err := history.Load(path, show)
+			if err != nil {
+				cmd.PrintErrf("Failed to load snapshot: %v\n", err)
+				return
+			}
+			cmd.Printf("# %s\n\n", snapshot.Title)
+			cmd.Print(render.Markdown(snapshot.Content, render.Options{URL: absoluteURL}))
+			return
+		}
+
+		snapshots, err := history.List(path)
+		if err != nil {
+			cmd.PrintErrf("Failed to read history: %v\n", err)
+			return
+		}
+		if len(snapshots) == 0 {
+			cmd.Printf("No history for %s\n", path)
+			return
+		}
+
+		for _, s := range snapshots {
+			cmd.Printf("%4d  %s  %-8s  %s\n", s.Rev, s.Time.Local().Format("2006-01-02 15:04:05"), s.Reason, s.Title)
+		}
+	},
+}
+
+// pageRollbackCmd represents the page rollback command
+var pageRollbackCmd = &cobra.Command{
+	Use:   "rollback <path> [rev]",
+	Short: "Re-publish a saved snapshot of a page",
+	Args:  cobra.RangeArgs(1, 2),
+	Long: `Restore the title, author and content of a page from a snapshot listed by
+page history. Without a revision the latest snapshot is used. The current
+version of the page is snapshotted first, so a rollback can be undone.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		path := args[0]
+
+		accessToken, err := token.GetToken()
+		if err != nil {
+			cmd.PrintErrf("Failed to get token: %v\n", err)
+			return
+		}
+
+		var snapshot history.Snapshot
+		if len(args) == 2 {
+			rev, err := strconv.Atoi(args[1])
+			if err != nil {
+				cmd.PrintErrf("Invalid revision %q\n", args[1])
+				return
+			}
+			snapshot, err = history.Load(path, rev)
+		} else {
+			snapshot, err = history.Latest(path)
+		}
+		if err != nil {
+			cmd.PrintErrf("Failed to load snapshot: %v\n", err)
+			return
+		}
+
+		current, err := fetchPage(ctx, path, true)
+		if err != nil {
+			cmd.PrintErrf("Failed to get current page after retries: %v\n", err)
+			return
+		}
+		if err := snapshotPage(current, "rollback"); err != nil {
+			cmd.PrintErrf("Failed to snapshot page: %v\n", err)
+			return
+		}
+
+		page, err := restoreSnapshot(ctx, accessToken, path, snapshot)
+		if err != nil {
+			cmd.PrintErrf("Failed to roll back page after retries: %v\n", err)
+			return
+		}
+
+		st, err := state.Load()
+		if err != nil {
+			cmd.PrintErrf("Failed to load sync state: %v\n", err)
+			return
+		}
+		recordHash(st, page)
+		if err := st.Save(); err != nil {
+			cmd.PrintErrf("Failed to record content hash: %v\n", err)
+		}
+
+		cmd.Printf("Rolled back %s to revision %d\n", path, snapshot.Rev)
+		cmd.Println("Title:", page.Title)
+		cmd.Println("URL:", page.URL)
+	},
+}
+
+// snapshotPage saves the title, author and content of a page fetched with
+// its content to the local history
+func snapshotPage(page *telegraph.Page, reason string) error {
+	s := pageSnapshot(page)
+	s.Reason = reason
+
+	_, err := history.Save(page.Path, s)
+	return err
+}
+
+// pageSnapshot returns the title, author and content of a page
+func pageSnapshot(page *telegraph.Page) history.Snapshot {
+	s := history.Snapshot{
+		Title:   titleOf(page),
+		Content: page.Content,
+	}
+	if page.AuthorName != nil {
+		s.AuthorName = page.AuthorName.String()
+	}
+	if page.AuthorURL != nil && page.AuthorURL.URL != nil {
+		s.AuthorURL = page.AuthorURL.String()
+	}
+	return s
+}
+
+// restoreSnapshot edits a page to the title, author and content of a snapshot
+func restoreSnapshot(ctx context.Context, accessToken, path string, s history.Snapshot) (*telegraph.Page, error) {
+	title, err := telegraph.NewTitle(s.Title)
+	if err != nil {
+		return nil, err
+	}
+
+	editPage := telegraph.EditPage{
+		AccessToken:   accessToken,
+		Path:          path,
+		Title:         *title,
+		Content:       s.Content,
+		ReturnContent: true,
+	}
+	if s.AuthorName != "" {
+		if editPage.AuthorName, err = telegraph.NewAuthorName(s.AuthorName); err != nil {
+			return nil, err
+		}
+	}
+	if s.AuthorURL != "" {
+		u, err := url.Parse(s.AuthorURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid author URL: %v", err)
+		}
+		editPage.AuthorURL = telegraph.NewURL(u)
+	}
+
+	var page *telegraph.Page
+	err = retry(func() error {
+		var e error
+		page, e = editPage.Do(ctx, newAPIClient())
+		return e
+	}, 3)
+	return page, err
+}
+
+func init() {
+	pageCmd.AddCommand(pageHistoryCmd)
+	pageCmd.AddCommand(pageRollbackCmd)
+
+	pageHistoryCmd.Flags().Int("show", 0, "Print the content of this revision as Markdown")
+}
@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+
+	"telegraphcli/pkg/state"
+)
+
+// listFormats are the output formats of page list
+var listFormats = []string{"text", "json", "csv"}
+
+// pageFilter selects pages by title, author, views and the tags and category
+// of their synced file
+type pageFilter struct {
+	Title    *regexp.Regexp
+	Author   string
+	MinViews int
+	MaxViews int // negative for no maximum
+	Tag      string
+	Category string
+
+	entries map[string]state.Entry
+}
+
+// addFilterFlags adds the flags read by newPageFilter
+func addFilterFlags(c *cobra.Command) {
+	c.Flags().String("match", "", "Only pages whose title matches this regular expression")
+	c.Flags().String("author", "", "Only pages by this author")
+	c.Flags().Int("min-views", 0, "Only pages with at least this many views")
+	c.Flags().Int("max-views", -1, "Only pages with at most this many views")
+	c.Flags().String("tag", "", "Only pages whose synced file has this tag")
+	c.Flags().String("category", "", "Only pages whose synced file has this category")
+}
+
+// newPageFilter reads the filter flags of a command
+func newPageFilter(cmd *cobra.Command) (*pageFilter, error) {
+	f := &pageFilter{}
+	f.Author, _ = cmd.Flags().GetString("author")
+	f.MinViews, _ = cmd.Flags().GetInt("min-views")
+	f.MaxViews, _ = cmd.Flags().GetInt("max-views")
+	f.Tag, _ = cmd.Flags().GetString("tag")
+	f.Category, _ = cmd.Flags().GetString("category")
+
+	if pattern, _ := cmd.Flags().GetString("match"); pattern != "" {
+		var err error
+		if f.Title, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid --match: %v", err)
+		}
+	}
+
+	if f.Tag != "" || f.Category != "" {
+		st, err := state.Load()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load sync state: %v", err)
+		}
+		f.entries = entriesByPath(st)
+	}
+	return f, nil
+}
+
+// Active reports whether the filter excludes any pages
+func (f *pageFilter) Active() bool {
+	return f.Title != nil || f.Author != "" || f.MinViews > 0 || f.MaxViews >= 0 || f.Tag != "" || f.Category != ""
+}
+
+// NeedsState reports whether the filter uses the sync state, which only
+// covers some pages, so that all pages have to be fetched
+func (f *pageFilter) NeedsState() bool {
+	return f.entries != nil
+}
+
+// Match reports whether a page passes the filter
+func (f *pageFilter) Match(page *telegraph.Page) bool {
+	if f.Title != nil && !f.Title.MatchString(titleOf(page)) {
+		return false
+	}
+	if f.Author != "" && !strings.EqualFold(authorOf(page), f.Author) {
+		return false
+	}
+	if int(page.Views) < f.MinViews || (f.MaxViews >= 0 && int(page.Views) > f.MaxViews) {
+		return false
+	}
+	if f.entries != nil {
+		entry, ok := f.entries[page.Path]
+		if !ok || (f.Tag != "" && !entry.HasTag(f.Tag)) || (f.Category != "" && !strings.EqualFold(entry.Category, f.Category)) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortPages orders pages by title or path ascending, or by views descending
+func sortPages(pages []telegraph.Page, by string, reverse bool) error {
+	var less func(a, b *telegraph.Page) bool
+	switch by {
+	case "":
+		if reverse {
+			for i, j := 0, len(pages)-1; i < j; i, j = i+1, j-1 {
+				pages[i], pages[j] = pages[j], pages[i]
+			}
+		}
+		return nil
+	case "title":
+		less = func(a, b *telegraph.Page) bool { return strings.ToLower(titleOf(a)) < strings.ToLower(titleOf(b)) }
+	case "path":
+		less = func(a, b *telegraph.Page) bool { return a.Path < b.Path }
+	case "views":
+		less = func(a, b *telegraph.Page) bool { return a.Views > b.Views }
+	default:
+		return fmt.Errorf("unknown sort %q, use title, views or path", by)
+	}
+
+	sort.SliceStable(pages, func(i, j int) bool {
+		if reverse {
+			return less(&pages[j], &pages[i])
+		}
+		return less(&pages[i], &pages[j])
+	})
+	return nil
+}
+
+// pageListWriter writes page list output. Begin is called once before the
+// first page, when the account's page count is known.
+type pageListWriter interface {
+	Begin(total uint)
+	Page(page *telegraph.Page)
+	End() error
+}
+
+// newPageListWriter returns a writer for one of listFormats
+func newPageListWriter(w io.Writer, format string, showViews, filtered bool) (pageListWriter, error) {
+	switch format {
+	case "text":
+		return &textListWriter{w: w, showViews: showViews, filtered: filtered}, nil
+	case "json":
+		return &jsonListWriter{w: w}, nil
+	case "csv":
+		return &csvListWriter{w: csv.NewWriter(w)}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q, use %s", format, strings.Join(listFormats, ", "))
+}
+
+// textListWriter writes the numbered list page list has always printed
+type textListWriter struct {
+	w         io.Writer
+	showViews bool
+	filtered  bool
+	count     int
+}
+
+func (t *textListWriter) Begin(total uint) {
+	fmt.Fprintf(t.w, "Total pages: %d\n", total)
+	fmt.Fprintln(t.w, "Pages:")
+}
+
+func (t *textListWriter) Page(page *telegraph.Page) {
+	t.count++
+	fmt.Fprintf(t.w, "%d. %s (%s)", t.count, titleOf(page), page.URL.String())
+	if t.showViews {
+		fmt.Fprintf(t.w, " - %d views", page.Views)
+	}
+	fmt.Fprintln(t.w)
+}
+
+func (t *textListWriter) End() error {
+	if t.filtered {
+		_, err := fmt.Fprintf(t.w, "Matching pages: %d\n", t.count)
+		return err
+	}
+	return nil
+}
+
+// listedPage is the JSON form of a page in page list output
+type listedPage struct {
+	Path        string `json:"path"`
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Author      string `json:"author,omitempty"`
+	Views       uint   `json:"views"`
+}
+
+// newListedPage returns the JSON form of a page
+func newListedPage(page *telegraph.Page) listedPage {
+	return listedPage{
+		Path:        page.Path,
+		URL:         page.URL.String(),
+		Title:       titleOf(page),
+		Description: page.Description,
+		Author:      authorOf(page),
+		Views:       page.Views,
+	}
+}
+
+// jsonListWriter writes a JSON array of pages
+type jsonListWriter struct {
+	w     io.Writer
+	pages []listedPage
+}
+
+func (j *jsonListWriter) Begin(total uint) {}
+
+func (j *jsonListWriter) Page(page *telegraph.Page) {
+	j.pages = append(j.pages, newListedPage(page))
+}
+
+func (j *jsonListWriter) End() error {
+	if j.pages == nil {
+		j.pages = []listedPage{}
+	}
+	data, err := json.MarshalIndent(j.pages, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(j.w, string(data))
+	return err
+}
+
+// csvListWriter writes one CSV row per page after a header row
+type csvListWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvListWriter) Begin(total uint) {
+	c.w.Write([]string{"path", "url", "title", "author", "views"})
+}
+
+func (c *csvListWriter) Page(page *telegraph.Page) {
+	c.w.Write([]string{page.Path, page.URL.String(), titleOf(page), authorOf(page), strconv.FormatUint(uint64(page.Views), 10)})
+	c.w.Flush()
+}
+
+func (c *csvListWriter) End() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/spf13/cobra"
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"gopkg.in/yaml.v3"
+
+	"telegraphcli/pkg/render"
+	"telegraphcli/pkg/state"
+)
+
+// pagePullCmd represents the page pull command
+var pagePullCmd = &cobra.Command{
+	Use:   "pull <path>...",
+	Short: "Save pages as local Markdown files",
+	Long: `Download pages and save each one as <path>.md in the --out directory, with
+the title and path in the front matter so the file can be edited and
+published again with page edit or sync. Existing files are only overwritten
+with --force.
+
+Several pages can be pulled at once from arguments, --from-file, stdin (-)
+or --list.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+
+		outDir, _ := cmd.Flags().GetString("out")
+		force, _ := cmd.Flags().GetBool("force")
+
+		paths, err := pagePaths(ctx, cmd, args)
+		if err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+		if err := os.MkdirAll(outDir, 0755); err != nil {
+			cmd.PrintErrf("Failed to create %s: %v\n", outDir, err)
+			return
+		}
+
+		st, err := state.Load()
+		if err != nil {
+			cmd.PrintErrf("Failed to load sync state: %v\n", err)
+			return
+		}
+		var mu sync.Mutex
+
+		pull := func(ctx context.Context, path string) (string, error) {
+			page, err := fetchPage(ctx, path, true)
+			if err != nil {
+				return "", err
+			}
+			file := filepath.Join(outDir, page.Path+".md")
+			if err := writePulledPage(file, page, force); err != nil {
+				return "", err
+			}
+
+			// The file now holds the live content, so editing it is not a
+			// conflict
+			mu.Lock()
+			recordHash(st, page)
+			mu.Unlock()
+			return file, nil
+		}
+
+		if isBulk(cmd, args) {
+			failed := runBulk(ctx, cmd, "Pulling", paths, pull)
+			if err := st.Save(); err != nil {
+				cmd.PrintErrf("Failed to record content hashes: %v\n", err)
+			}
+			if failed > 0 {
+				os.Exit(1)
+			}
+			return
+		}
+
+		file, err := pull(ctx, paths[0])
+		if err != nil {
+			cmd.PrintErrf("Failed to pull %s: %v\n", paths[0], err)
+			return
+		}
+		if err := st.Save(); err != nil {
+			cmd.PrintErrf("Failed to record content hash: %v\n", err)
+		}
+		cmd.Printf("Saved %s to %s\n", paths[0], file)
+	},
+}
+
+// pulledFrontMatter is the front matter written at the top of pulled pages
+type pulledFrontMatter struct {
+	Title string `yaml:"title"`
+	Path  string `yaml:"path"`
+}
+
+// writePulledPage writes a page as Markdown with front matter
+func writePulledPage(file string, page *telegraph.Page, force bool) error {
+	if _, err := os.Stat(file); err == nil && !force {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", file)
+	}
+
+	frontMatter, err := yaml.Marshal(pulledFrontMatter{Title: titleOf(page), Path: page.Path})
+	if err != nil {
+		return err
+	}
+	content := "---\n" + string(frontMatter) + "---\n\n" + render.Markdown(page.Content, render.Options{URL: absoluteURL})
+
+	return os.WriteFile(file, []byte(content), 0644)
+}
+
+func init() {
+	pageCmd.AddCommand(pagePullCmd)
+
+	pagePullCmd.Flags().StringP("out", "o", ".", "Directory to write the Markdown files to")
+	pagePullCmd.Flags().Bool("force", false, "Overwrite existing files")
+	addBulkFlags(pagePullCmd)
+}
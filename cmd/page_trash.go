@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+
+	"telegraphcli/pkg/history"
+	"telegraphcli/pkg/state"
+	"telegraphcli/pkg/token"
+	"telegraphcli/pkg/trash"
+)
+
+// pageTrashCmd represents the page trash command
+var pageTrashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "Manage pages removed with page delete",
+	Long: `Pages removed with page delete are kept in ~/.telegraphcl/trash until
+they are restored with page restore or purged.`,
+}
+
+// pageTrashListCmd represents the page trash list command
+var pageTrashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List deleted pages",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		items, err := trash.List()
+		if err != nil {
+			cmd.PrintErrf("Failed to read trash: %v\n", err)
+			return
+		}
+		if len(items) == 0 {
+			cmd.Println("The trash is empty")
+			return
+		}
+
+		for _, item := range items {
+			cmd.Printf("%s  %s  %s\n", item.DeletedAt.Local().Format("2006-01-02 15:04"), item.Path, item.Title)
+		}
+	},
+}
+
+// pageTrashPurgeCmd represents the page trash purge command
+var pageTrashPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Permanently remove old pages from the trash",
+	Args:  cobra.NoArgs,
+	Long: `Remove pages deleted longer ago than --older-than from the trash. The age
+accepts Go durations such as 12h as well as days and weeks such as 30d or 2w.
+Use --older-than 0 to empty the trash.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		olderThan, _ := cmd.Flags().GetString("older-than")
+		age, err := parseAge(olderThan)
+		if err != nil {
+			cmd.PrintErrf("Invalid --older-than: %v\n", err)
+			return
+		}
+
+		purged, err := trash.Purge(time.Now().Add(-age))
+		for _, item := range purged {
+			cmd.Printf("Purged %s (%s)\n", item.Path, item.Title)
+		}
+		if err != nil {
+			cmd.PrintErrf("Failed to purge trash: %v\n", err)
+			return
+		}
+		cmd.Printf("Purged %s\n", pluralPages(len(purged)))
+	},
+}
+
+// pageRestoreCmd represents the page restore command
+var pageRestoreCmd = &cobra.Command{
+	Use:   "restore <path>",
+	Short: "Restore a deleted page from the trash",
+	Args:  cobra.ExactArgs(1),
+	Long: `Re-publish the title, author and content a page had before page delete and
+remove it from the trash.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		path := args[0]
+
+		accessToken, err := token.GetToken()
+		if err != nil {
+			cmd.PrintErrf("Failed to get token: %v\n", err)
+			return
+		}
+
+		item, err := trash.Get(path)
+		if err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+
+		page, err := restoreSnapshot(ctx, accessToken, path, history.Snapshot{
+			Title:      item.Title,
+			AuthorName: item.AuthorName,
+			AuthorURL:  item.AuthorURL,
+			Content:    item.Content,
+		})
+		if err != nil {
+			cmd.PrintErrf("Failed to restore page after retries: %v\n", err)
+			return
+		}
+
+		if err := trash.Remove(path); err != nil {
+			cmd.PrintErrf("%v\n", err)
+		}
+
+		st, err := state.Load()
+		if err != nil {
+			cmd.PrintErrf("Failed to load sync state: %v\n", err)
+			return
+		}
+		recordHash(st, page)
+		if err := st.Save(); err != nil {
+			cmd.PrintErrf("Failed to record content hash: %v\n", err)
+		}
+
+		cmd.Println("Page restored successfully!")
+		cmd.Println("Title:", page.Title)
+		cmd.Println("URL:", page.URL)
+	},
+}
+
+// trashPage keeps a page fetched with its content in the trash. A page still
+// in the trash is a tombstone being deleted again, so the article kept when
+// it was first deleted is left in place.
+func trashPage(page *telegraph.Page) error {
+	if _, err := trash.Get(page.Path); err == nil {
+		return nil
+	}
+	s := pageSnapshot(page)
+	return trash.Put(trash.Item{
+		Path:       page.Path,
+		URL:        page.URL.String(),
+		Title:      s.Title,
+		AuthorName: s.AuthorName,
+		AuthorURL:  s.AuthorURL,
+		Content:    s.Content,
+	})
+}
+
+// confirm asks a yes/no question on the command's input and reports whether
+// it was answered with yes
+func confirm(cmd *cobra.Command, question string) bool {
+	cmd.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// parseAge parses a duration that may also be given in days (30d) or weeks (2w)
+func parseAge(s string) (time.Duration, error) {
+	if s == "0" {
+		return 0, nil
+	}
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, err := strconv.Atoi(strings.TrimSuffix(s, suffix)); err == nil && strings.HasSuffix(s, suffix) {
+			if n < 0 {
+				return 0, fmt.Errorf("negative age %q", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err == nil && d < 0 {
+		return 0, fmt.Errorf("negative age %q", s)
+	}
+	return d, err
+}
+
+func init() {
+	pageCmd.AddCommand(pageTrashCmd)
+	pageCmd.AddCommand(pageRestoreCmd)
+	pageTrashCmd.AddCommand(pageTrashListCmd)
+	pageTrashCmd.AddCommand(pageTrashPurgeCmd)
+
+	pageTrashPurgeCmd.Flags().String("older-than", "30d", "Purge pages deleted longer ago than this")
+}
@@ -0,0 +1,345 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+
+	"telegraphcli/pkg/bulk"
+	"telegraphcli/pkg/token"
+	"telegraphcli/pkg/views"
+)
+
+// viewsFormats are the output formats of page views over a date range
+var viewsFormats = []string{"table", "csv", "json", "chart", "spark"}
+
+// viewsPoint is the view count of one period of a series
+type viewsPoint struct {
+	Period string `json:"period"`
+	Views  uint   `json:"views"`
+}
+
+// rankedPage is the view count of one page over a date range
+type rankedPage struct {
+	Rank  int    `json:"rank"`
+	Path  string `json:"path"`
+	Title string `json:"title,omitempty"`
+	Views uint   `json:"views"`
+}
+
+// viewsRange reads --from and --to. ok is false when neither is set; --to
+// defaults to today and --from to the first day of the month of --to.
+func viewsRange(cmd *cobra.Command) (from, to time.Time, ok bool, err error) {
+	fromFlag, _ := cmd.Flags().GetString("from")
+	toFlag, _ := cmd.Flags().GetString("to")
+	if fromFlag == "" && toFlag == "" {
+		return from, to, false, nil
+	}
+
+	now := time.Now().UTC()
+	to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if toFlag != "" {
+		if to, err = views.ParseDate(toFlag); err != nil {
+			return from, to, false, err
+		}
+	}
+	from = time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if fromFlag != "" {
+		if from, err = views.ParseDate(fromFlag); err != nil {
+			return from, to, false, err
+		}
+	}
+	if to.Before(from) {
+		return from, to, false, fmt.Errorf("--to is before --from")
+	}
+	return from, to, true, nil
+}
+
+// countViews gets the views of a page in one bucket
+func countViews(ctx context.Context, path string, b views.Bucket) (uint, error) {
+	year, month, day, hour := b.Params()
+	getViews := telegraph.GetViews{
+		Path:  path,
+		Year:  uint16(year),
+		Month: uint8(month),
+		Day:   uint8(day),
+		Hour:  uint8(hour),
+	}
+
+	var pageViews *telegraph.PageViews
+	err := retry(func() error {
+		var e error
+		pageViews, e = getViews.Do(ctx, newAPIClient())
+		return e
+	}, 3)
+	if err != nil {
+		return 0, err
+	}
+	return pageViews.Views, nil
+}
+
+// viewsSeries prints the views of one page per period between from and to
+func viewsSeries(cmd *cobra.Command, path string, from, to time.Time) {
+	ctx := context.Background()
+
+	granularity, _ := cmd.Flags().GetString("granularity")
+	format, _ := cmd.Flags().GetString("format")
+	if err := checkViewsFormat(format); err != nil {
+		cmd.PrintErrf("%v\n", err)
+		return
+	}
+
+	buckets, err := views.Buckets(from, to, granularity)
+	if err != nil {
+		cmd.PrintErrf("%v\n", err)
+		return
+	}
+
+	labels := make([]string, len(buckets))
+	byLabel := map[string]views.Bucket{}
+	for i, b := range buckets {
+		labels[i] = b.Label()
+		byLabel[labels[i]] = b
+	}
+
+	results := runPool(ctx, cmd, "Counting views", labels, func(ctx context.Context, label string) (string, error) {
+		n, err := countViews(ctx, path, byLabel[label])
+		return strconv.FormatUint(uint64(n), 10), err
+	})
+	if bulk.Failed(results) > 0 {
+		for _, r := range results {
+			if r.Err != nil {
+				cmd.PrintErrf("Failed to get views for %s: %v\n", r.Item, r.Err)
+			}
+		}
+		os.Exit(1)
+	}
+
+	series := make([]viewsPoint, len(results))
+	var total uint
+	for i, r := range results {
+		n, _ := strconv.ParseUint(r.Detail, 10, 64)
+		series[i] = viewsPoint{Period: r.Item, Views: uint(n)}
+		total += uint(n)
+	}
+
+	if err := writeViewsSeries(cmd.OutOrStdout(), format, path, granularity, from, to, series, total); err != nil {
+		cmd.PrintErrf("Failed to write views: %v\n", err)
+	}
+}
+
+// writeViewsSeries writes a series in one of viewsFormats
+func writeViewsSeries(w io.Writer, format, path, granularity string, from, to time.Time, series []viewsPoint, total uint) error {
+	labels := make([]string, len(series))
+	values := make([]uint, len(series))
+	for i, p := range series {
+		labels[i], values[i] = p.Period, p.Views
+	}
+
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"period", "views"})
+		for _, p := range series {
+			cw.Write([]string{p.Period, strconv.FormatUint(uint64(p.Views), 10)})
+		}
+		cw.Flush()
+		return cw.Error()
+	case "json":
+		data, err := json.MarshalIndent(struct {
+			Path        string       `json:"path"`
+			Granularity string       `json:"granularity"`
+			From        string       `json:"from"`
+			To          string       `json:"to"`
+			Total       uint         `json:"total"`
+			Series      []viewsPoint `json:"series"`
+		}{path, granularity, from.Format("2006-01-02"), to.Format("2006-01-02"), total, series}, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "chart":
+		if err := views.Bars(w, labels, values, 50); err != nil {
+			return err
+		}
+	case "spark":
+		fmt.Fprintf(w, "%s %s\n", path, views.Sparkline(values))
+	default:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(tw, "PERIOD\tVIEWS\t")
+		for _, p := range series {
+			fmt.Fprintf(tw, "%s\t%d\t\n", p.Period, p.Views)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "Total: %d views from %s to %s\n", total, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	return err
+}
+
+// rankViews ranks pages by their views between from and to, or by their
+// total views when there is no range
+func rankViews(cmd *cobra.Command, args []string, from, to time.Time, ranged bool) {
+	ctx := context.Background()
+
+	format, _ := cmd.Flags().GetString("format")
+	top, _ := cmd.Flags().GetInt("top")
+	if err := checkViewsFormat(format); err != nil {
+		cmd.PrintErrf("%v\n", err)
+		return
+	}
+	if format == "spark" {
+		cmd.PrintErrf("The spark format is only available for a single page\n")
+		return
+	}
+
+	allPages, _ := cmd.Flags().GetBool("all-pages")
+	if !allPages && !ranged {
+		cmd.PrintErrf("Ranking pages needs --all-pages or a range with --from and --to\n")
+		return
+	}
+
+	var ranked []rankedPage
+	if allPages {
+		accessToken, err := token.GetToken()
+		if err != nil {
+			cmd.PrintErrf("Failed to get token: %v\n", err)
+			return
+		}
+		filter, err := newPageFilter(cmd)
+		if err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+		pages, err := fetchAllPages(ctx, accessToken)
+		if err != nil {
+			cmd.PrintErrf("Failed to get page list after retries: %v\n", err)
+			return
+		}
+		for i := range pages {
+			if filter.Match(&pages[i]) {
+				ranked = append(ranked, rankedPage{Path: pages[i].Path, Title: titleOf(&pages[i]), Views: pages[i].Views})
+			}
+		}
+	} else {
+		paths, err := pagePaths(ctx, cmd, args)
+		if err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+		for _, path := range paths {
+			ranked = append(ranked, rankedPage{Path: path})
+		}
+	}
+
+	// Count the range with the fewest calls per page: whole years and months
+	// where possible
+	failed := 0
+	if ranged {
+		buckets := views.Cover(from, to)
+		paths := make([]string, len(ranked))
+		for i, r := range ranked {
+			paths[i] = r.Path
+		}
+		results := runPool(ctx, cmd, "Counting views", paths, func(ctx context.Context, path string) (string, error) {
+			var total uint
+			for _, b := range buckets {
+				n, err := countViews(ctx, path, b)
+				if err != nil {
+					return "", err
+				}
+				total += n
+			}
+			return strconv.FormatUint(uint64(total), 10), nil
+		})
+
+		counted := ranked[:0]
+		for i, r := range results {
+			if r.Err != nil {
+				cmd.PrintErrf("Failed to get views for %s: %v\n", r.Item, r.Err)
+				failed++
+				continue
+			}
+			n, _ := strconv.ParseUint(r.Detail, 10, 64)
+			ranked[i].Views = uint(n)
+			counted = append(counted, ranked[i])
+		}
+		ranked = counted
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Views > ranked[j].Views })
+	if top > 0 && len(ranked) > top {
+		ranked = ranked[:top]
+	}
+	for i := range ranked {
+		ranked[i].Rank = i + 1
+	}
+
+	if err := writeRanking(cmd.OutOrStdout(), format, ranked); err != nil {
+		cmd.PrintErrf("Failed to write views: %v\n", err)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// writeRanking writes ranked pages in one of viewsFormats
+func writeRanking(w io.Writer, format string, ranked []rankedPage) error {
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"rank", "path", "title", "views"})
+		for _, r := range ranked {
+			cw.Write([]string{strconv.Itoa(r.Rank), r.Path, r.Title, strconv.FormatUint(uint64(r.Views), 10)})
+		}
+		cw.Flush()
+		return cw.Error()
+	case "json":
+		if ranked == nil {
+			ranked = []rankedPage{}
+		}
+		data, err := json.MarshalIndent(ranked, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "chart":
+		labels := make([]string, len(ranked))
+		values := make([]uint, len(ranked))
+		for i, r := range ranked {
+			labels[i], values[i] = r.Path, r.Views
+		}
+		return views.Bars(w, labels, values, 50)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RANK\tVIEWS\tPATH\tTITLE")
+	for _, r := range ranked {
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\n", r.Rank, r.Views, r.Path, r.Title)
+	}
+	return tw.Flush()
+}
+
+// checkViewsFormat reports an unknown --format
+func checkViewsFormat(format string) error {
+	for _, f := range viewsFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown format %q, use %s", format, strings.Join(viewsFormats, ", "))
+}
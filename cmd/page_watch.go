@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+
+	"telegraphcli/pkg/markdown"
+	"telegraphcli/pkg/state"
+	"telegraphcli/pkg/token"
+	"telegraphcli/pkg/upload"
+	"telegraphcli/pkg/watch"
+)
+
+// pageWatchCmd represents the page watch command
+var pageWatchCmd = &cobra.Command{
+	Use:   "watch <file> [path]",
+	Short: "Re-publish a page every time its file is saved",
+	Args:  cobra.RangeArgs(1, 2),
+	Long: `Watch a Markdown file and the local images it references, and edit the page
+whenever they change, until interrupted.
+
+The page is the path given, or the page the file was published to with sync
+or the path in its front matter. Changes are debounced, so a burst of saves
+publishes once, and nothing is sent when neither the parsed content nor the
+title changed. Local images and images embedded in notebooks are uploaded to
+telegra.ph, again only when they change. Errors are printed as they happen
+and watching goes on.
+
+Files are watched with inotify on Linux and polled every --interval
+elsewhere or with --poll.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		accessToken, err := token.GetToken()
+		if err != nil {
+			cmd.PrintErrf("Failed to get token: %v\n", err)
+			return
+		}
+
+		w := &pageWatcher{cmd: cmd, token: accessToken, file: args[0], uploads: map[[sha256.Size]byte]string{}}
+		if len(args) == 2 {
+			w.path = pathFromArg(args[1])
+		} else if w.path, err = watchedPagePath(w.file); err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+
+		current, err := fetchPage(ctx, w.path, true)
+		if err != nil {
+			cmd.PrintErrf("Failed to get page after retries: %v\n", err)
+			return
+		}
+		st, err := state.Load()
+		if err != nil {
+			cmd.PrintErrf("Failed to load sync state: %v\n", err)
+			return
+		}
+		if force, _ := cmd.Flags().GetBool("force"); !force {
+			if err := checkConflict(st, current); err != nil {
+				cmd.PrintErrf("Conflict: %v, use --force to overwrite it\n", err)
+				return
+			}
+		}
+		w.current = current
+		w.lastHash = contentHash(current.Content)
+		w.lastTitle = titleOf(current)
+
+		interval, _ := cmd.Flags().GetDuration("interval")
+		poll, _ := cmd.Flags().GetBool("poll")
+		w.watcher = watch.New(interval, poll)
+		defer w.watcher.Close()
+
+		cmd.Printf("Watching %s for %s (%s, Ctrl-C to stop)\n", w.file, current.URL, w.watcher.Mode)
+		w.publish(ctx)
+
+		debounce, _ := cmd.Flags().GetDuration("debounce")
+		timer := time.NewTimer(debounce)
+		timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				cmd.Println("Stopped watching")
+				return
+			case <-w.watcher.Events:
+				timer.Reset(debounce)
+			case <-timer.C:
+				w.publish(ctx)
+			}
+		}
+	},
+}
+
+// pageWatcher publishes a file to its page whenever it changes
+type pageWatcher struct {
+	cmd     *cobra.Command
+	token   string
+	file    string
+	path    string
+	watcher *watch.Watcher
+
+	// current is the page as fetched when watching started
+	current *telegraph.Page
+	// lastHash and lastTitle are the content hash and title last sent
+	lastHash  string
+	lastTitle string
+	// uploads maps the SHA-256 of uploaded images to their URL
+	uploads     map[[sha256.Size]byte]string
+	snapshotted bool
+}
+
+// publish parses the file, uploads changed images and edits the page if the
+// content changed, printing the outcome
+func (w *pageWatcher) publish(ctx context.Context) {
+	now := time.Now().Format("15:04:05")
+	fail := func(format string, args ...interface{}) {
+		w.cmd.PrintErrf("%s Error: %s\n", now, fmt.Sprintf(format, args...))
+	}
+
+	nodes, err := parseContentWith(w.cmd, w.file, func(data []byte, contentType string) (string, error) {
+		return w.upload(ctx, embeddedImageName(contentType), data, contentType)
+	})
+	if err != nil {
+		fail("%v", err)
+		w.watch(nil)
+		return
+	}
+	dir := filepath.Dir(w.file)
+	images := localImages(dir, nodes)
+	w.watch(images)
+	if err := w.uploadImages(ctx, dir, nodes); err != nil {
+		fail("%v", err)
+		return
+	}
+
+	title, _ := w.cmd.Flags().GetString("title")
+	if title == "" {
+		title = readFrontMatter(w.file).Title
+	}
+	if title == "" {
+		title = titleOf(w.current)
+	}
+
+	hash := contentHash(nodes)
+	if hash == w.lastHash && title == w.lastTitle {
+		if verbose, _ := w.cmd.Flags().GetBool("verbose"); verbose {
+			w.cmd.Printf("%s Unchanged\n", now)
+		}
+		return
+	}
+
+	// Keep one history entry per watch session rather than one per save
+	if !w.snapshotted {
+		if err := snapshotPage(w.current, "edit"); err != nil {
+			fail("failed to snapshot page: %v", err)
+			return
+		}
+		w.snapshotted = true
+	}
+
+	page, err := savePage(ctx, w.token, w.path, title, nodes)
+	if err != nil {
+		fail("%v", err)
+		return
+	}
+	w.lastHash, w.lastTitle = hash, title
+
+	st, err := state.Load()
+	if err == nil {
+		recordHash(st, page)
+		err = st.Save()
+	}
+	if err != nil {
+		fail("failed to record content hash: %v", err)
+	}
+	w.cmd.Printf("%s Published %s\n", now, page.URL)
+}
+
+// watch watches the file and the given images
+func (w *pageWatcher) watch(images []string) {
+	if err := w.watcher.Watch(append([]string{w.file}, images...)); err != nil {
+		w.cmd.PrintErrf("Failed to watch: %v\n", err)
+	}
+}
+
+// uploadImages replaces the src of local images, relative to dir, with their
+// telegra.ph URL, uploading the images that are new or changed
+func (w *pageWatcher) uploadImages(ctx context.Context, dir string, nodes []telegraph.Node) error {
+	for i := range nodes {
+		n := &nodes[i]
+		if n.Element == nil {
+			continue
+		}
+		if src, ok := localFile(dir, n.Element.Attrs["src"]); n.Element.Tag.Atom() == atom.Img && ok {
+			data, err := os.ReadFile(src)
+			if err != nil {
+				return err
+			}
+			imageURL, err := w.upload(ctx, filepath.Base(src), data, http.DetectContentType(data))
+			if err != nil {
+				return err
+			}
+			n.Element.Attrs["src"] = imageURL
+		}
+		if err := w.uploadImages(ctx, dir, n.Element.Children); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upload uploads an image unless the same image was uploaded before, and
+// returns its URL
+func (w *pageWatcher) upload(ctx context.Context, name string, data []byte, contentType string) (string, error) {
+	sum := sha256.Sum256(data)
+	if imageURL, ok := w.uploads[sum]; ok {
+		return imageURL, nil
+	}
+	imageURL, err := upload.Upload(ctx, newAPIClient(), name, data, contentType)
+	if err != nil {
+		return "", err
+	}
+	w.uploads[sum] = imageURL
+	return imageURL, nil
+}
+
+// localImages returns the local files, relative to dir, referenced by img
+// nodes
+func localImages(dir string, nodes []telegraph.Node) []string {
+	var images []string
+	for _, n := range nodes {
+		if n.Element == nil {
+			continue
+		}
+		if src, ok := localFile(dir, n.Element.Attrs["src"]); n.Element.Tag.Atom() == atom.Img && ok {
+			images = append(images, src)
+		}
+		images = append(images, localImages(dir, n.Element.Children)...)
+	}
+	return images
+}
+
+// localFile returns the path of the existing local file src names, relative
+// to dir, and false for URLs and missing files
+func localFile(dir, src string) (string, bool) {
+	if u, err := url.Parse(src); src == "" || err != nil || u.Scheme != "" || u.Host != "" {
+		return "", false
+	}
+	if !filepath.IsAbs(src) {
+		src = filepath.Join(dir, src)
+	}
+	info, err := os.Stat(src)
+	return src, err == nil && info.Mode().IsRegular()
+}
+
+// watchedPagePath returns the page a file was published to, from the sync
+// state or its front matter
+func watchedPagePath(file string) (string, error) {
+	st, err := state.Load()
+	if err != nil {
+		return "", fmt.Errorf("failed to load sync state: %v", err)
+	}
+	if entry, ok := st.Lookup(file); ok {
+		return entry.Path, nil
+	}
+	if path := readFrontMatter(file).Path; path != "" {
+		return path, nil
+	}
+	return "", fmt.Errorf("%s has not been published, give the page path or publish it with page create or sync first", file)
+}
+
+func init() {
+	pageCmd.AddCommand(pageWatchCmd)
+
+	pageWatchCmd.Flags().StringP("title", "t", "", "Title of the page (default: from the front matter, or the current title)")
+	pageWatchCmd.Flags().Duration("debounce", 300*time.Millisecond, "Quiet time after a change before publishing")
+	pageWatchCmd.Flags().Bool("poll", false, "Poll for changes instead of using inotify")
+	pageWatchCmd.Flags().Duration("interval", time.Second, "How often files are polled")
+	pageWatchCmd.Flags().Bool("force", false, "Overwrite the page even if it was changed on telegra.ph")
+	pageWatchCmd.Flags().StringP("format", "f", "", "Input format: "+strings.Join(markdown.Formats(), ", ")+" (default: from file extension)")
+}
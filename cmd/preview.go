@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"telegraphcli/pkg/markdown"
+	"telegraphcli/pkg/render"
+	"telegraphcli/pkg/watch"
+)
+
+// previewScript reloads the preview when the server sends a reload event
+const previewScript = `<script>
+new EventSource("/events").addEventListener("reload", function () { location.reload(); });
+</script>
+`
+
+// previewCmd represents the preview command
+var previewCmd = &cobra.Command{
+	Use:   "preview <file>",
+	Short: "Preview a page in the browser before publishing it",
+	Args:  cobra.ExactArgs(1),
+	Long: `Serve a local preview of a Markdown file, converted as page create would and
+styled like telegra.ph. Local images are served from disk, and the browser
+reloads by itself whenever the file or its images change.
+
+No token or API calls are needed. Images embedded in notebooks, which are
+uploaded when publishing, are left out.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		p := &previewServer{cmd: cmd, file: args[0], clients: map[chan struct{}]bool{}}
+
+		interval, _ := cmd.Flags().GetDuration("interval")
+		poll, _ := cmd.Flags().GetBool("poll")
+		p.watcher = watch.New(interval, poll)
+		defer p.watcher.Close()
+		p.render()
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /{$}", p.serveDocument)
+		mux.HandleFunc("GET /events", p.serveEvents)
+		mux.HandleFunc("GET /files/{key}/{name}", p.serveFile)
+
+		listen, _ := cmd.Flags().GetString("listen")
+		srv := &http.Server{Addr: listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		cmd.Printf("Previewing %s at http://%s/\n", p.file, previewHost(listen))
+		err := serveHTTP(cmd, srv, func(ctx context.Context) {
+			p.ctx = ctx
+			go p.watch(ctx)
+		})
+		if err != nil {
+			cmd.PrintErrf("Server failed: %v\n", err)
+		}
+	},
+}
+
+// previewServer renders a file and tells browsers to reload when it changes
+type previewServer struct {
+	cmd     *cobra.Command
+	file    string
+	watcher *watch.Watcher
+	// ctx is canceled on shutdown, ending the event streams
+	ctx context.Context
+
+	mu       sync.Mutex
+	document string
+	// files maps the keys in /files URLs to the local images of the document
+	files   map[string]string
+	clients map[chan struct{}]bool
+}
+
+// render converts the file and watches it along with its local images
+func (p *previewServer) render() {
+	nodes, err := convertContent(p.cmd, p.file, markdown.Options{})
+
+	files := map[string]string{}
+	body := render.HTML(nodes, render.Options{
+		URL: func(attr, value string) string {
+			if attr != "src" {
+				return value
+			}
+			file, ok := localFile(filepath.Dir(p.file), value)
+			if !ok {
+				return value
+			}
+			sum := sha256.Sum256([]byte(file))
+			key := hex.EncodeToString(sum[:6])
+			files[key] = file
+			return "/files/" + key + "/" + url.PathEscape(filepath.Base(file))
+		},
+	})
+	if err != nil {
+		body = `<p class="tl_error">` + html.EscapeString(err.Error()) + `</p>`
+		p.cmd.PrintErrf("%s Error: %v\n", time.Now().Format("15:04:05"), err)
+	}
+
+	title, _ := p.cmd.Flags().GetString("title")
+	if title == "" {
+		title = readFrontMatter(p.file).Title
+	}
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(p.file), filepath.Ext(p.file))
+	}
+
+	watched := []string{p.file}
+	for _, f := range files {
+		watched = append(watched, f)
+	}
+	if err := p.watcher.Watch(watched); err != nil {
+		p.cmd.PrintErrf("Failed to watch: %v\n", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.document = render.Document(render.Page{
+		Title: title,
+		Body:  body,
+		Head:  "<style>.tl_error { color: #c00; font-family: Menlo, Courier, monospace; }</style>\n" + previewScript,
+	})
+	p.files = files
+}
+
+// watch re-renders the document after changes and notifies the browsers
+func (p *previewServer) watch(ctx context.Context) {
+	debounce, _ := p.cmd.Flags().GetDuration("debounce")
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.watcher.Events:
+			timer.Reset(debounce)
+		case <-timer.C:
+			p.render()
+			p.reload()
+			if verbose, _ := p.cmd.Flags().GetBool("verbose"); verbose {
+				p.cmd.Printf("%s Reloaded\n", time.Now().Format("15:04:05"))
+			}
+		}
+	}
+}
+
+// reload sends a reload event to every connected browser
+func (p *previewServer) reload() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for c := range p.clients {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (p *previewServer) serveDocument(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	document := p.document
+	p.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(w, document)
+}
+
+// serveFile serves a local image of the document, and no other files
+func (p *previewServer) serveFile(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	file, ok := p.files[r.PathValue("key")]
+	p.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	http.ServeFile(w, r, file)
+}
+
+// serveEvents streams server-sent events until the browser disconnects or
+// the server shuts down
+func (p *previewServer) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	c := make(chan struct{}, 1)
+	p.mu.Lock()
+	p.clients[c] = true
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.clients, c)
+		p.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-p.ctx.Done():
+			return
+		case <-c:
+			fmt.Fprint(w, "event: reload\ndata: {}\n\n")
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		flusher.Flush()
+	}
+}
+
+// previewHost returns the host to open for a listen address
+func previewHost(listen string) string {
+	if strings.HasPrefix(listen, ":") {
+		return "localhost" + listen
+	}
+	return listen
+}
+
+func init() {
+	rootCmd.AddCommand(previewCmd)
+
+	previewCmd.Flags().String("listen", "localhost:8040", "Address to listen on")
+	previewCmd.Flags().StringP("title", "t", "", "Title of the page (default: from the front matter or the file name)")
+	previewCmd.Flags().StringP("format", "f", "", "Input format: "+strings.Join(markdown.Formats(), ", ")+" (default: from file extension)")
+	previewCmd.Flags().Duration("debounce", 100*time.Millisecond, "Quiet time after a change before reloading")
+	previewCmd.Flags().Bool("poll", false, "Poll for changes instead of using inotify")
+	previewCmd.Flags().Duration("interval", time.Second, "How often files are polled")
+}
@@ -27,6 +27,13 @@ It allows you to create and manage users, create and edit pages, and more.`,
 		// Initialize HTTP client using the function from pkg/http/client.go
 		httpClient = pkgHttpClient.CreateHTTPClientWithRetry() // Use renamed import
 
+		// Share one rate limiter between all requests, including concurrent ones
+		rate, _ := cmd.Flags().GetFloat64("rate")
+		httpClient.Transport = &pkgHttpClient.RateLimitedTransport{
+			Base:    httpClient.Transport,
+			Limiter: pkgHttpClient.NewRateLimiter(rate),
+		}
+
 		// Add option to debug HTTP requests
 		if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
 			fmt.Println("Using custom HTTP client with User-Agent:", userAgent)
@@ -47,4 +54,5 @@ func Execute() {
 
 func init() {
 	// Add any global flags here
+	rootCmd.PersistentFlags().Float64("rate", 5, "Maximum Telegraph API requests per second (0 for no limit)")
 }
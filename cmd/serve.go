@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// shutdownTimeout is how long servers wait for open requests when stopping
+const shutdownTimeout = 10 * time.Second
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run long-running HTTP servers",
+	Long: `Run telegraphcl as a daemon. Servers stop gracefully on SIGINT or SIGTERM,
+finishing the requests in flight first.`,
+}
+
+// serveHTTP runs srv until it fails or the process receives SIGINT or
+// SIGTERM. The context passed to start is canceled on shutdown, so background
+// work started with it stops along with the server.
+func serveHTTP(cmd *cobra.Command, srv *http.Server, start func(ctx context.Context)) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if start != nil {
+		start(ctx)
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+	cmd.Printf("Listening on %s\n", srv.Addr)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	cmd.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// setFlagDefaults sets the flags that were not given on the command line to
+// values from the config file, skipping empty values
+func setFlagDefaults(cmd *cobra.Command, values map[string]string) error {
+	for name, value := range values {
+		if value == "" || cmd.Flags().Changed(name) {
+			continue
+		}
+		if err := cmd.Flags().Set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+
+	"telegraphcli/pkg/config"
+	"telegraphcli/pkg/markdown"
+	"telegraphcli/pkg/state"
+)
+
+// maxAPIBody is the largest request body serve api accepts
+const maxAPIBody = 10 << 20
+
+// apiTimeout bounds the Telegraph API calls of one REST request
+const apiTimeout = 2 * time.Minute
+
+// serveAPICmd represents the serve api command
+var serveAPICmd = &cobra.Command{
+	Use:   "api",
+	Short: "Publish pages through a local REST API",
+	Args:  cobra.NoArgs,
+	Long: `Serve a REST API that lets other programs publish without the CLI:
+
+  GET  /pages          list the pages of the client's profile
+  GET  /pages/{path}   get one page
+  POST /pages          create a page from Markdown with front matter
+  PUT  /pages/{path}   replace the content of a page
+
+Requests are authenticated with "Authorization: Bearer <key>" or an X-API-Key
+header. Each key is mapped to a profile, the account it publishes as, in
+~/.telegraphcl/config.yaml:
+
+  profiles:
+    docs:
+      token_file: ~/.telegraphcl/docs.token
+  serve:
+    api:
+      listen: 127.0.0.1:9301
+      keys:
+        - name: ci
+          key: a-long-random-secret
+          profile: docs
+
+The profile "default" is the current user. Pages are returned in the JSON
+schema of page list --format json. The title is taken from the front matter
+or the title query parameter; PUT keeps the current title when neither is
+given, and refuses to overwrite pages changed on telegra.ph unless force=true.
+PUTs to the same page are handled one at a time, so a second one conflicts
+with the first instead of overwriting it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			cmd.PrintErrf("Failed to load config: %v\n", err)
+			return
+		}
+		if err := setFlagDefaults(cmd, map[string]string{"listen": cfg.Serve.API.Listen}); err != nil {
+			cmd.PrintErrf("Invalid serve.api config: %v\n", err)
+			return
+		}
+
+		server, err := newAPIServer(cmd, cfg)
+		if err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+
+		listen, _ := cmd.Flags().GetString("listen")
+		srv := &http.Server{Addr: listen, Handler: server.routes(), ReadHeaderTimeout: 10 * time.Second}
+		if err := serveHTTP(cmd, srv, nil); err != nil {
+			cmd.PrintErrf("Server failed: %v\n", err)
+		}
+	},
+}
+
+// apiClient is an authenticated client of the REST API
+type apiClient struct {
+	Name  string
+	Key   string
+	Token string
+}
+
+// apiServer handles the REST API requests
+type apiServer struct {
+	cmd     *cobra.Command
+	clients []apiClient
+
+	// stateMu serializes updates of the sync state and the page history
+	stateMu sync.Mutex
+	// pathMu guards pathLocks, which serialize edits of one page so that
+	// two requests cannot pass the conflict check against the same hash
+	pathMu    sync.Mutex
+	pathLocks map[string]*sync.Mutex
+}
+
+// lockPath locks a page path for an edit and returns the unlock function
+func (s *apiServer) lockPath(path string) func() {
+	s.pathMu.Lock()
+	if s.pathLocks == nil {
+		s.pathLocks = map[string]*sync.Mutex{}
+	}
+	mu, ok := s.pathLocks[path]
+	if !ok {
+		mu = &sync.Mutex{}
+		s.pathLocks[path] = mu
+	}
+	s.pathMu.Unlock()
+
+	mu.Lock()
+	return mu.Unlock
+}
+
+// newAPIServer resolves the profile of every configured key
+func newAPIServer(cmd *cobra.Command, cfg *config.Config) (*apiServer, error) {
+	if len(cfg.Serve.API.Keys) == 0 {
+		return nil, fmt.Errorf("no API keys configured in serve.api.keys")
+	}
+
+	s := &apiServer{cmd: cmd}
+	for i, k := range cfg.Serve.API.Keys {
+		name := k.Name
+		if name == "" {
+			name = fmt.Sprintf("key %d", i+1)
+		}
+		if len(k.Key) < 16 {
+			return nil, fmt.Errorf("API key of %s must be at least 16 characters", name)
+		}
+		accessToken, err := cfg.ProfileToken(k.Profile)
+		if err != nil {
+			return nil, fmt.Errorf("API key of %s: %v", name, err)
+		}
+		s.clients = append(s.clients, apiClient{Name: name, Key: k.Key, Token: accessToken})
+	}
+	return s, nil
+}
+
+// routes returns the handler of all endpoints
+func (s *apiServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /pages", s.auth(s.listPages))
+	mux.HandleFunc("POST /pages", s.auth(s.createPage))
+	mux.HandleFunc("GET /pages/{path}", s.auth(s.getPage))
+	mux.HandleFunc("PUT /pages/{path}", s.auth(s.editPage))
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	return mux
+}
+
+// auth checks the API key of a request and passes the matching client on
+func (s *apiServer) auth(next func(w http.ResponseWriter, r *http.Request, c *apiClient)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			key = bearer
+		}
+
+		var client *apiClient
+		for i := range s.clients {
+			if subtle.ConstantTimeCompare([]byte(key), []byte(s.clients[i].Key)) == 1 {
+				client = &s.clients[i]
+			}
+		}
+		if key == "" || client == nil {
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid API key"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), apiTimeout)
+		defer cancel()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next(rec, r.WithContext(ctx), client)
+		s.cmd.Printf("%s %s %s %d %s\n", client.Name, r.Method, r.URL.Path, rec.status, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+func (s *apiServer) listPages(w http.ResponseWriter, r *http.Request, c *apiClient) {
+	pages, err := fetchAllPages(r.Context(), c.Token)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	listed := []listedPage{}
+	for i := range pages {
+		listed = append(listed, newListedPage(&pages[i]))
+	}
+	writeJSON(w, http.StatusOK, listed)
+}
+
+func (s *apiServer) getPage(w http.ResponseWriter, r *http.Request, c *apiClient) {
+	page, err := fetchPage(r.Context(), r.PathValue("path"), false)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newListedPage(page))
+}
+
+func (s *apiServer) createPage(w http.ResponseWriter, r *http.Request, c *apiClient) {
+	title, nodes, err := readAPIContent(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if title == "" {
+		writeError(w, http.StatusBadRequest, errors.New("no title in the front matter or the title parameter"))
+		return
+	}
+
+	page, err := savePage(r.Context(), c.Token, "", title, nodes)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	s.recordHash(page)
+	writeJSON(w, http.StatusCreated, newListedPage(page))
+}
+
+func (s *apiServer) editPage(w http.ResponseWriter, r *http.Request, c *apiClient) {
+	title, nodes, err := readAPIContent(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Held until the new hash is recorded
+	unlock := s.lockPath(r.PathValue("path"))
+	defer unlock()
+
+	current, err := fetchPage(r.Context(), r.PathValue("path"), true)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	if title == "" {
+		title = titleOf(current)
+	}
+
+	s.stateMu.Lock()
+	st, err := state.Load()
+	s.stateMu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := checkConflict(st, current); err != nil && r.URL.Query().Get("force") != "true" {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	s.stateMu.Lock()
+	err = snapshotPage(current, "edit")
+	s.stateMu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	page, err := savePage(r.Context(), c.Token, current.Path, title, nodes)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	s.recordHash(page)
+	writeJSON(w, http.StatusOK, newListedPage(page))
+}
+
+// recordHash records the content hash of a published page in the sync state
+func (s *apiServer) recordHash(page *telegraph.Page) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	st, err := state.Load()
+	if err == nil {
+		recordHash(st, page)
+		err = st.Save()
+	}
+	if err != nil {
+		s.cmd.PrintErrf("Failed to record content hash of %s: %v\n", page.Path, err)
+	}
+}
+
+// readAPIContent parses the Markdown body of a request and returns the title
+// from its front matter or the title parameter
+func readAPIContent(w http.ResponseWriter, r *http.Request) (string, []telegraph.Node, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxAPIBody))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read body: %v", err)
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return "", nil, errors.New("empty body, send the page as Markdown")
+	}
+
+	nodes, err := markdown.ParseReader(bytes.NewReader(body), markdown.Options{})
+	if err != nil {
+		return "", nil, err
+	}
+
+	title := r.URL.Query().Get("title")
+	if title == "" {
+		frontMatter, _ := markdown.ReadFrontMatter(bytes.NewReader(body))
+		title = frontMatter.Title
+	}
+	return title, nodes, nil
+}
+
+// writeJSON writes v as an indented JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		status, data = http.StatusInternalServerError, []byte(`{"error": "failed to encode response"}`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
+
+// writeError writes an error as {"error": "..."}
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// statusRecorder remembers the status code written to a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func init() {
+	serveCmd.AddCommand(serveAPICmd)
+
+	serveAPICmd.Flags().String("listen", "127.0.0.1:9301", "Address to listen on")
+}
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+
+	"telegraphcli/pkg/config"
+	pkgHttpClient "telegraphcli/pkg/http"
+	"telegraphcli/pkg/metrics"
+	"telegraphcli/pkg/token"
+)
+
+// minMetricsInterval is the shortest refresh interval serve metrics accepts
+const minMetricsInterval = 30 * time.Second
+
+// serveMetricsCmd represents the serve metrics command
+var serveMetricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Expose page views as Prometheus metrics",
+	Args:  cobra.NoArgs,
+	Long: `Serve a Prometheus /metrics endpoint with the views of every page, the
+account's page count and Telegraph API request, error and latency counters.
+
+Page views are refreshed in the background every --interval (at least 30s)
+and scrapes are answered from that cache, so scraping never calls the API.
+The page list flags select which pages are exported. Defaults can be set in
+the serve.metrics section of ~/.telegraphcl/config.yaml:
+
+  serve:
+    metrics:
+      listen: ":9300"
+      interval: 10m
+      min_views: 10`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			cmd.PrintErrf("Failed to load config: %v\n", err)
+			return
+		}
+		m := cfg.Serve.Metrics
+		defaults := map[string]string{
+			"listen":   m.Listen,
+			"interval": m.Interval,
+			"match":    m.Match,
+			"author":   m.Author,
+			"tag":      m.Tag,
+			"category": m.Category,
+		}
+		if m.MinViews > 0 {
+			defaults["min-views"] = strconv.Itoa(m.MinViews)
+		}
+		err = setFlagDefaults(cmd, defaults)
+		if err != nil {
+			cmd.PrintErrf("Invalid serve.metrics config: %v\n", err)
+			return
+		}
+
+		interval, _ := cmd.Flags().GetDuration("interval")
+		if interval < minMetricsInterval {
+			cmd.PrintErrf("Interval must be at least %s\n", minMetricsInterval)
+			return
+		}
+		filter, err := newPageFilter(cmd)
+		if err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+
+		accessToken, err := token.GetToken()
+		if err != nil {
+			cmd.PrintErrf("Failed to get token: %v\n", err)
+			return
+		}
+
+		exporter := &metricsExporter{
+			token:  accessToken,
+			filter: filter,
+			api:    metrics.NewAPIStats(),
+		}
+		apiStats = exporter.api
+		// Measure requests below the rate limiter so waiting for it does not
+		// count as API latency
+		if limited, ok := httpClient.Transport.(*pkgHttpClient.RateLimitedTransport); ok {
+			limited.Base = &metrics.Transport{Base: limited.Base, Stats: exporter.api}
+		} else {
+			httpClient.Transport = &metrics.Transport{Base: httpClient.Transport, Stats: exporter.api}
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", exporter)
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprintln(w, `<html><body><a href="/metrics">Metrics</a></body></html>`)
+		})
+
+		listen, _ := cmd.Flags().GetString("listen")
+		srv := &http.Server{Addr: listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		err = serveHTTP(cmd, srv, func(ctx context.Context) {
+			go exporter.run(ctx, interval)
+		})
+		if err != nil {
+			cmd.PrintErrf("Server failed: %v\n", err)
+		}
+	},
+}
+
+// metricsExporter caches the page views fetched by its refresh loop and
+// writes them with the API counters on every scrape
+type metricsExporter struct {
+	token  string
+	filter *pageFilter
+	api    *metrics.APIStats
+
+	mu        sync.RWMutex
+	pages     []telegraph.Page
+	total     uint
+	refreshed time.Time
+	took      time.Duration
+	ok        bool
+	failures  float64
+}
+
+// run refreshes the cache immediately and then every interval until ctx is
+// canceled
+func (e *metricsExporter) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		e.refresh(ctx, interval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh fetches the page list once. On failure the previous page views are
+// kept and only the refresh status changes.
+func (e *metricsExporter) refresh(ctx context.Context, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	var pages []telegraph.Page
+	var total uint
+	err := forEachPage(ctx, e.token, func(batch []telegraph.Page, count uint) error {
+		total = count
+		for i := range batch {
+			if e.filter.Match(&batch[i]) {
+				pages = append(pages, batch[i])
+			}
+		}
+		return nil
+	})
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.took = time.Since(start)
+	e.ok = err == nil
+	if err != nil {
+		e.failures++
+		return
+	}
+	e.pages, e.total, e.refreshed = pages, total, time.Now()
+}
+
+// ServeHTTP writes the cached metrics in the Prometheus text format
+func (e *metricsExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", metrics.ContentType)
+	metrics.Write(w, append(e.families(), e.api.Families()...))
+}
+
+// families returns the page and refresh metrics
+func (e *metricsExporter) families() []metrics.Family {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	views := metrics.Family{Name: "telegraph_page_views", Help: "Total views of a page.", Type: metrics.Gauge}
+	for i := range e.pages {
+		views.Samples = append(views.Samples, metrics.Sample{
+			Labels: map[string]string{"path": e.pages[i].Path},
+			Value:  float64(e.pages[i].Views),
+		})
+	}
+
+	gauge := func(name, help string, value float64) metrics.Family {
+		return metrics.Family{Name: name, Help: help, Type: metrics.Gauge, Samples: []metrics.Sample{{Value: value}}}
+	}
+	var ok, refreshed float64
+	if e.ok {
+		ok = 1
+	}
+	if !e.refreshed.IsZero() {
+		refreshed = float64(e.refreshed.Unix())
+	}
+
+	return []metrics.Family{
+		views,
+		gauge("telegraph_account_pages", "Pages of the account, exported or not.", float64(e.total)),
+		gauge("telegraph_refresh_success", "Whether the last refresh of page views succeeded.", ok),
+		gauge("telegraph_refresh_timestamp_seconds", "Unix time of the last successful refresh.", refreshed),
+		gauge("telegraph_refresh_duration_seconds", "Duration of the last refresh.", e.took.Seconds()),
+		{Name: "telegraph_refresh_errors_total", Help: "Refreshes of page views that failed.", Type: metrics.Counter, Samples: []metrics.Sample{{Value: e.failures}}},
+	}
+}
+
+func init() {
+	serveCmd.AddCommand(serveMetricsCmd)
+
+	serveMetricsCmd.Flags().String("listen", ":9300", "Address to listen on")
+	serveMetricsCmd.Flags().Duration("interval", 5*time.Minute, "How often page views are refreshed (at least 30s)")
+	addFilterFlags(serveMetricsCmd)
+}
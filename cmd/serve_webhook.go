@@ -0,0 +1,466 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"telegraphcli/pkg/config"
+	"telegraphcli/pkg/state"
+	"telegraphcli/pkg/webhook"
+)
+
+// maxWebhookBody is the largest delivery serve webhook accepts
+const maxWebhookBody = 25 << 20
+
+// webhookQueueSize is how many pushes can wait to be published
+const webhookQueueSize = 100
+
+// serveWebhookCmd represents the serve webhook command
+var serveWebhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Publish Markdown files changed by git pushes",
+	Args:  cobra.NoArgs,
+	Long: `Receive GitHub, Gitea or Gogs push webhooks and publish the Markdown files
+changed on --branch, as sync would, without touching the other files.
+
+Deliveries must be signed with the shared --secret (HMAC-SHA256, the
+X-Hub-Signature-256 or X-Gitea-Signature header). The changed files are read
+from --dir, a local checkout updated with git pull when --pull is given. When
+the payload carries a "files" array of {"path", "content"} objects, the
+changed Markdown files among them are written to --dir instead; payloads with
+paths into .git are rejected. Changed paths that lead outside of --dir, also
+through symbolic links, or into .git are not published.
+
+Every delivery is logged with its outcome to ~/.telegraphcl/webhook.jsonl;
+pushes still queued at shutdown are logged as failed, to be redelivered from
+the sender. Redeliveries of a delivery or commit that was already published
+are acknowledged without publishing again. Defaults can be set in the
+serve.webhook section of ~/.telegraphcl/config.yaml:
+
+  serve:
+    webhook:
+      listen: ":9302"
+      secret: a-long-random-secret
+      dir: ~/src/docs
+      pull: true
+      prefix: docs`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			cmd.PrintErrf("Failed to load config: %v\n", err)
+			return
+		}
+		w := cfg.Serve.Webhook
+		defaults := map[string]string{
+			"listen":  w.Listen,
+			"secret":  w.Secret,
+			"dir":     w.Dir,
+			"branch":  w.Branch,
+			"prefix":  w.Prefix,
+			"profile": w.Profile,
+		}
+		if w.Pull {
+			defaults["pull"] = "true"
+		}
+		err = setFlagDefaults(cmd, defaults)
+		if err != nil {
+			cmd.PrintErrf("Invalid serve.webhook config: %v\n", err)
+			return
+		}
+
+		server := &webhookServer{cmd: cmd, queue: make(chan webhookJob, webhookQueueSize)}
+		flags := cmd.Flags()
+		server.secret, _ = flags.GetString("secret")
+		if server.secret == "" {
+			server.secret = os.Getenv("TELEGRAPHCL_WEBHOOK_SECRET")
+		}
+		if server.secret == "" {
+			cmd.PrintErrf("A secret is required, set --secret or TELEGRAPHCL_WEBHOOK_SECRET\n")
+			return
+		}
+		dir, _ := flags.GetString("dir")
+		if dir == "" {
+			cmd.PrintErrf("A directory is required, set --dir\n")
+			return
+		}
+		server.dir = config.ExpandHome(dir)
+		server.branch, _ = flags.GetString("branch")
+		server.prefix, _ = flags.GetString("prefix")
+		server.pull, _ = flags.GetBool("pull")
+		server.opts.TagFooter, _ = flags.GetBool("tag-footer")
+		server.opts.Force, _ = flags.GetBool("force")
+
+		profile, _ := flags.GetString("profile")
+		if server.token, err = cfg.ProfileToken(profile); err != nil {
+			cmd.PrintErrf("Failed to get token: %v\n", err)
+			return
+		}
+		if err := server.loadDeliveries(); err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("POST /", server)
+
+		listen, _ := flags.GetString("listen")
+		srv := &http.Server{Addr: listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		done := make(chan struct{})
+		err = serveHTTP(cmd, srv, func(ctx context.Context) {
+			go server.work(ctx, done)
+		})
+		if err != nil {
+			cmd.PrintErrf("Server failed: %v\n", err)
+		}
+		// Let the push being published finish. Senders do not redeliver
+		// pushes that were accepted, so the queued ones are logged as failed
+		// to be redelivered by hand.
+		<-done
+		server.abandon()
+	},
+}
+
+// webhookJob is a push waiting to be published
+type webhookJob struct {
+	delivery webhook.Delivery
+	push     *webhook.Push
+}
+
+// webhookServer verifies deliveries and publishes their pushes one at a time
+type webhookServer struct {
+	cmd    *cobra.Command
+	secret string
+	token  string
+	dir    string
+	branch string
+	prefix string
+	pull   bool
+	opts   publishOptions
+	queue  chan webhookJob
+
+	// mu guards the IDs of handled and queued deliveries and the published
+	// commits, used to make redeliveries idempotent
+	mu         sync.Mutex
+	deliveries map[string]bool
+	commits    map[string]bool
+}
+
+// loadDeliveries reads the deliveries handled by earlier runs
+func (s *webhookServer) loadDeliveries() error {
+	deliveries, err := webhook.Load()
+	if err != nil {
+		return err
+	}
+
+	s.deliveries, s.commits = map[string]bool{}, map[string]bool{}
+	for _, d := range deliveries {
+		if d.Outcome != webhook.Failed {
+			s.deliveries[d.ID] = true
+		}
+		if d.Outcome == webhook.OK && d.After != "" {
+			s.commits[d.After] = true
+		}
+	}
+	return nil
+}
+
+// ServeHTTP verifies a delivery and queues its push
+func (s *webhookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to read body: %v", err))
+		return
+	}
+	if err := webhook.Verify(s.secret, body, r.Header); err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	event, id := webhook.Event(r.Header)
+	if event == "ping" {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "pong"})
+		return
+	}
+	if id == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing delivery ID header"))
+		return
+	}
+	d := webhook.Delivery{ID: id, Time: time.Now().UTC(), Event: event}
+
+	s.mu.Lock()
+	duplicate := s.deliveries[id]
+	s.deliveries[id] = true
+	s.mu.Unlock()
+	if duplicate {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "duplicate"})
+		return
+	}
+
+	status, reason := s.accept(&d, body)
+	if d.Outcome != "" {
+		s.record(d)
+		writeJSON(w, status, map[string]string{"status": d.Outcome, "reason": reason})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "queued"})
+}
+
+// accept queues the push of a delivery. When the delivery is not queued it
+// sets its outcome and returns the response status and reason.
+func (s *webhookServer) accept(d *webhook.Delivery, body []byte) (int, string) {
+	if d.Event != "push" {
+		d.Outcome, d.Reason = webhook.Ignored, "not a push event"
+		return http.StatusAccepted, d.Reason
+	}
+
+	push := &webhook.Push{}
+	if err := json.Unmarshal(body, push); err != nil {
+		d.Outcome, d.Reason = webhook.Failed, fmt.Sprintf("invalid payload: %v", err)
+		return http.StatusBadRequest, d.Reason
+	}
+	d.Repo, d.Ref, d.After = push.Repository.FullName, push.Ref, push.After
+
+	s.mu.Lock()
+	published := s.commits[push.After]
+	s.mu.Unlock()
+	switch {
+	case push.Branch() != s.branch:
+		d.Outcome, d.Reason = webhook.Ignored, fmt.Sprintf("not a push to %s", s.branch)
+		return http.StatusAccepted, d.Reason
+	case published:
+		d.Outcome, d.Reason = webhook.Ignored, "commit already published"
+		return http.StatusOK, d.Reason
+	}
+
+	select {
+	case s.queue <- webhookJob{delivery: *d, push: push}:
+		return http.StatusAccepted, ""
+	default:
+		d.Outcome, d.Reason = webhook.Failed, "queue full"
+		return http.StatusServiceUnavailable, d.Reason
+	}
+}
+
+// work publishes queued pushes until ctx is canceled, then closes done
+func (s *webhookServer) work(ctx context.Context, done chan<- struct{}) {
+	defer close(done)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-s.queue:
+			s.record(s.publish(job))
+		}
+	}
+}
+
+// abandon logs the pushes still queued as failed
+func (s *webhookServer) abandon() {
+	for {
+		select {
+		case job := <-s.queue:
+			d := job.delivery
+			d.Outcome, d.Reason = webhook.Failed, "shut down before publishing"
+			s.record(d)
+		default:
+			return
+		}
+	}
+}
+
+// publish brings the changed files of a push into the directory and
+// publishes them
+func (s *webhookServer) publish(job webhookJob) webhook.Delivery {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	d := job.delivery
+	changed, removed := job.push.Changed(s.prefix)
+	d.Removed = removed
+	fail := func(reason string) webhook.Delivery {
+		d.Outcome, d.Reason = webhook.Failed, reason
+		return d
+	}
+
+	if len(job.push.Files) > 0 {
+		if err := writePushFiles(s.dir, job.push.Files, changed); err != nil {
+			return fail(err.Error())
+		}
+	} else if s.pull {
+		out, err := exec.CommandContext(ctx, "git", "-C", s.dir, "pull", "--ff-only").CombinedOutput()
+		if err != nil {
+			return fail(fmt.Sprintf("git pull failed: %v: %s", err, out))
+		}
+	}
+
+	d.Errors = map[string]string{}
+	var files []string
+	repoPaths := map[string]string{}
+	for _, f := range changed {
+		name := filepath.FromSlash(f)
+		if !filepath.IsLocal(name) || inGitDir(name) {
+			d.Errors[f] = "invalid file path"
+			continue
+		}
+		file := filepath.Join(s.dir, name)
+		if err := insideDir(s.dir, file); err != nil {
+			d.Errors[f] = err.Error()
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			d.Errors[f] = "not found in " + s.dir
+			continue
+		}
+		files = append(files, file)
+		repoPaths[file] = f
+	}
+
+	st, err := state.Load()
+	if err != nil {
+		return fail(fmt.Sprintf("failed to load sync state: %v", err))
+	}
+	_, failed := syncFiles(ctx, s.cmd, s.token, st, files, s.opts, false)
+	for _, file := range files {
+		if err, ok := failed[file]; ok {
+			d.Errors[repoPaths[file]] = err.Error()
+		} else {
+			d.Published = append(d.Published, repoPaths[file])
+		}
+	}
+
+	d.Outcome = webhook.OK
+	if len(d.Errors) > 0 {
+		d.Outcome, d.Reason = webhook.Failed, fmt.Sprintf("%d of %d files failed", len(d.Errors), len(changed))
+	}
+	return d
+}
+
+// record logs the outcome of a delivery. Failed deliveries are forgotten so
+// that a redelivery tries again.
+func (s *webhookServer) record(d webhook.Delivery) {
+	s.mu.Lock()
+	switch d.Outcome {
+	case webhook.Failed:
+		delete(s.deliveries, d.ID)
+	case webhook.OK:
+		if d.After != "" {
+			s.commits[d.After] = true
+		}
+	}
+	s.mu.Unlock()
+
+	line := fmt.Sprintf("Delivery %s (%s): %s", d.ID, strings.TrimSpace(d.Event+" "+d.Ref), d.Outcome)
+	if d.Reason != "" {
+		line += ", " + d.Reason
+	}
+	if len(d.Published) > 0 {
+		line += fmt.Sprintf(", %d published", len(d.Published))
+	}
+	s.cmd.Println(line)
+	for file, err := range d.Errors {
+		s.cmd.PrintErrf("  %s: %s\n", file, err)
+	}
+
+	if err := webhook.Append(d); err != nil {
+		s.cmd.PrintErrf("Failed to log delivery %s: %v\n", d.ID, err)
+	}
+}
+
+// writePushFiles writes the file contents of a payload below dir. Only the
+// changed Markdown files are written; other files in the payload are ignored.
+func writePushFiles(dir string, files []webhook.File, changed []string) error {
+	publish := map[string]bool{}
+	for _, f := range changed {
+		publish[path.Clean(f)] = true
+	}
+
+	for _, f := range files {
+		name := filepath.FromSlash(f.Path)
+		if !filepath.IsLocal(name) || inGitDir(name) {
+			return fmt.Errorf("invalid file path %q in payload", f.Path)
+		}
+		if !publish[path.Clean(f.Path)] {
+			continue
+		}
+		file := filepath.Join(dir, name)
+		if err := insideDir(dir, file); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, []byte(f.Content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insideDir returns an error when file, with symbolic links resolved, is not
+// below dir. Parts of file that do not exist yet are taken as they are.
+func insideDir(dir, file string) error {
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+
+	existing, rest := file, ""
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(root, filepath.Join(resolved, rest))
+	if err != nil || !filepath.IsLocal(rel) {
+		return fmt.Errorf("%s resolves to outside of %s", file, dir)
+	}
+	return nil
+}
+
+// inGitDir reports whether a relative path is inside a .git directory
+func inGitDir(name string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(name), "/") {
+		if strings.EqualFold(part, ".git") {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	serveCmd.AddCommand(serveWebhookCmd)
+
+	serveWebhookCmd.Flags().String("listen", ":9302", "Address to listen on")
+	serveWebhookCmd.Flags().String("secret", "", "Shared secret deliveries are signed with (default $TELEGRAPHCL_WEBHOOK_SECRET)")
+	serveWebhookCmd.Flags().String("dir", "", "Local checkout, or the directory payload files are written to")
+	serveWebhookCmd.Flags().String("branch", "main", "Branch to publish")
+	serveWebhookCmd.Flags().String("prefix", "", "Only publish files below this repository directory")
+	serveWebhookCmd.Flags().Bool("pull", false, "Run git pull --ff-only in --dir before publishing")
+	serveWebhookCmd.Flags().String("profile", "", "Profile to publish as (default: the current user)")
+	serveWebhookCmd.Flags().Bool("tag-footer", false, "Add a list of the page's tags to the end of each page")
+	serveWebhookCmd.Flags().Bool("force", false, "Overwrite pages even if they were changed on telegra.ph")
+}
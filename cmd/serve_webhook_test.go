@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"telegraphcli/pkg/webhook"
+)
+
+func TestInsideDir(t *testing.T) {
+	outside := t.TempDir()
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "notes.md"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "docs", "guide.md"), []byte("# Guide"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "notes.md"), filepath.Join(dir, "docs", "notes.md")); err != nil {
+		t.Skipf("symbolic links not supported: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "elsewhere")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("guide.md", filepath.Join(dir, "docs", "alias.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file   string
+		inside bool
+	}{
+		{"docs/guide.md", true},
+		{"docs/new/page.md", true},
+		{"docs/alias.md", true},
+		{"docs/notes.md", false},
+		{"elsewhere/notes.md", false},
+		{"elsewhere/new/page.md", false},
+	}
+	for _, tt := range tests {
+		err := insideDir(dir, filepath.Join(dir, filepath.FromSlash(tt.file)))
+		if (err == nil) != tt.inside {
+			t.Errorf("insideDir(%s) = %v, want inside %v", tt.file, err, tt.inside)
+		}
+	}
+}
+
+func TestWritePushFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := []webhook.File{
+		{Path: "docs/a.md", Content: "# A"},
+		{Path: "docs/b.txt", Content: "not published"},
+	}
+	if err := writePushFiles(dir, files, []string{"docs/a.md"}); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "docs", "a.md")); err != nil || string(data) != "# A" {
+		t.Errorf("docs/a.md = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "docs", "b.txt")); !os.IsNotExist(err) {
+		t.Errorf("docs/b.txt was written")
+	}
+
+	for _, bad := range []string{"../a.md", "/tmp/a.md", ".git/hooks/a.md", "docs/.GIT/a.md"} {
+		err := writePushFiles(dir, []webhook.File{{Path: bad}}, []string{bad})
+		if err == nil {
+			t.Errorf("writePushFiles accepted %q", bad)
+		}
+	}
+}
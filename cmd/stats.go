@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"telegraphcli/pkg/stats"
+	"telegraphcli/pkg/token"
+	"telegraphcli/pkg/views"
+)
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Track page views over time",
+	Long: `The views API only returns totals, so stats collect records the views of
+every page in ~/.telegraphcl/stats.jsonl and stats report compares those
+snapshots over an interval.`,
+}
+
+// statsCollectCmd represents the stats collect command
+var statsCollectCmd = &cobra.Command{
+	Use:   "collect",
+	Short: "Record the current views of every page",
+	Args:  cobra.NoArgs,
+	Long: `Record the views of every page of the account. Run it regularly, for
+example hourly or daily from cron:
+
+  0 * * * * telegraphcl stats collect --quiet`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		accessToken, err := token.GetToken()
+		if err != nil {
+			cmd.PrintErrf("Failed to get token: %v\n", err)
+			return
+		}
+
+		pages, err := fetchAllPages(ctx, accessToken)
+		if err != nil {
+			cmd.PrintErrf("Failed to get page list after retries: %v\n", err)
+			return
+		}
+
+		snapshot := stats.Snapshot{Time: time.Now().UTC()}
+		for i := range pages {
+			snapshot.Pages = append(snapshot.Pages, stats.PageViews{
+				Path:  pages[i].Path,
+				Title: titleOf(&pages[i]),
+				Views: pages[i].Views,
+			})
+		}
+		if err := stats.Append(snapshot); err != nil {
+			cmd.PrintErrf("Failed to record views: %v\n", err)
+			return
+		}
+
+		if quiet, _ := cmd.Flags().GetBool("quiet"); !quiet {
+			cmd.Printf("Recorded %d views on %s\n", snapshot.Total(), pluralPages(len(snapshot.Pages)))
+		}
+	},
+}
+
+// statsReportCmd represents the stats report command
+var statsReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Show how views changed over an interval",
+	Args:  cobra.NoArgs,
+	Long: `Compare the snapshots recorded by stats collect over an interval, the last
+--since (default 7d) or --from to --to (YYYY-MM-DD, UTC), and show the total
+change, the pages that gained the most views, new pages, pages without new
+views and pages that disappeared.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		to := time.Now().UTC()
+		since, _ := cmd.Flags().GetString("since")
+		age, err := parseAge(since)
+		if err != nil {
+			cmd.PrintErrf("Invalid --since: %v\n", err)
+			return
+		}
+		from := to.Add(-age)
+
+		if toFlag, _ := cmd.Flags().GetString("to"); toFlag != "" {
+			day, err := views.ParseDate(toFlag)
+			if err != nil {
+				cmd.PrintErrf("%v\n", err)
+				return
+			}
+			to = day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			from = to.Add(-age)
+		}
+		if fromFlag, _ := cmd.Flags().GetString("from"); fromFlag != "" {
+			if from, err = views.ParseDate(fromFlag); err != nil {
+				cmd.PrintErrf("%v\n", err)
+				return
+			}
+		}
+
+		snapshots, err := stats.Load()
+		if err != nil {
+			cmd.PrintErrf("Failed to load stats: %v\n", err)
+			return
+		}
+		report, err := stats.NewReport(snapshots, from, to)
+		if err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+
+		format, _ := cmd.Flags().GetString("format")
+		top, _ := cmd.Flags().GetInt("top")
+		switch format {
+		case "json":
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				cmd.PrintErrf("Failed to encode report: %v\n", err)
+				return
+			}
+			cmd.Println(string(data))
+		case "text":
+			writeStatsReport(cmd.OutOrStdout(), report, top)
+		default:
+			cmd.PrintErrf("Unknown format %q, use text or json\n", format)
+		}
+	},
+}
+
+// writeStatsReport writes a report as text, listing at most top pages per
+// section
+func writeStatsReport(w io.Writer, r *stats.Report, top int) {
+	fmt.Fprintf(w, "Views from %s to %s (%d snapshots)\n", r.From.Local().Format("2006-01-02 15:04"), r.To.Local().Format("2006-01-02 15:04"), r.Snapshots)
+	fmt.Fprintf(w, "Total: %d (%+d)\n", r.After, int64(r.After)-int64(r.Before))
+
+	section := func(title string, changes []stats.Change, line func(stats.Change) string) {
+		fmt.Fprintf(w, "\n%s: %d\n", title, len(changes))
+		for i, c := range changes {
+			if top > 0 && i == top {
+				fmt.Fprintf(w, "  ... %d more\n", len(changes)-top)
+				break
+			}
+			fmt.Fprintf(w, "  %s  %s  %s\n", line(c), c.Path, c.Title)
+		}
+	}
+	section("Top movers", r.Movers, func(c stats.Change) string { return fmt.Sprintf("%+8d", c.Delta) })
+	section("New pages", r.New, func(c stats.Change) string { return fmt.Sprintf("%8d", c.After) })
+	section("Stale pages", r.Stale, func(c stats.Change) string { return fmt.Sprintf("%8d", c.After) })
+	if len(r.Gone) > 0 {
+		section("Gone", r.Gone, func(c stats.Change) string { return fmt.Sprintf("%8d", c.Before) })
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.AddCommand(statsCollectCmd)
+	statsCmd.AddCommand(statsReportCmd)
+
+	statsCollectCmd.Flags().BoolP("quiet", "q", false, "Print nothing unless there is an error")
+
+	statsReportCmd.Flags().String("since", "7d", "Length of the interval, such as 24h, 7d or 4w")
+	statsReportCmd.Flags().String("from", "", "Start of the interval, YYYY-MM-DD (overrides --since)")
+	statsReportCmd.Flags().String("to", "", "Last day of the interval, YYYY-MM-DD (default: now)")
+	statsReportCmd.Flags().Int("top", 10, "Pages listed per section (0 for all)")
+	statsReportCmd.Flags().StringP("format", "f", "text", "Output format: text or json")
+}
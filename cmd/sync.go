@@ -0,0 +1,385 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+
+	"telegraphcli/pkg/markdown"
+	"telegraphcli/pkg/state"
+	"telegraphcli/pkg/token"
+)
+
+// syncCmd represents the sync command
+var syncCmd = &cobra.Command{
+	Use:   "sync <dir>",
+	Short: "Publish a directory of Markdown files",
+	Args:  cobra.ExactArgs(1),
+	Long: `Publish every Markdown file in a directory to Telegra.ph.
+Files that were published before, either recorded in ~/.telegraphcl/sync.json
+or with a path in their front matter, are edited; other files are created.
+
+Relative links between the files are rewritten to the telegra.ph URLs of the
+target pages, and anchors are mapped to Telegraph heading anchors. Files that
+link to each other are first created with placeholder content and then
+edited with the links filled in; if that fails, the error names the
+placeholder page left behind. Links to files outside the directory that have
+not been published are reported as errors.
+
+The tags and category in the front matter are recorded in the sync state.
+With --tag-footer a list of the page's tags, linking to the pages made by
+tags publish, is added to the end of each page.
+
+Pages changed on telegra.ph since they were last published are not
+overwritten unless --force is given; the differences are shown instead.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		dir := args[0]
+
+		var opts publishOptions
+		opts.TagFooter, _ = cmd.Flags().GetBool("tag-footer")
+		opts.Force, _ = cmd.Flags().GetBool("force")
+		opts.WriteRemote, _ = cmd.Flags().GetBool("write-remote")
+
+		accessToken, err := token.GetToken()
+		if err != nil {
+			cmd.PrintErrf("Failed to get token: %v\n", err)
+			return
+		}
+
+		files, err := markdownFiles(dir)
+		if err != nil {
+			cmd.PrintErrf("Failed to list markdown files: %v\n", err)
+			return
+		}
+
+		st, err := state.Load()
+		if err != nil {
+			cmd.PrintErrf("Failed to load sync state: %v\n", err)
+			return
+		}
+
+		published, failed := syncFiles(ctx, cmd, accessToken, st, files, opts, verbose)
+		cmd.Printf("Sync finished: %d published, %d failed\n", published, len(failed))
+		for file, err := range failed {
+			cmd.PrintErrf("  %s: %v\n", file, err)
+		}
+	},
+}
+
+// markdownFiles returns all Markdown files below dir
+func markdownFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && strings.HasPrefix(d.Name(), ".") && path != dir {
+			return filepath.SkipDir
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if strings.HasSuffix(path, ".remote.md") {
+			// Live versions written on conflicts
+			return nil
+		}
+		if !d.IsDir() && (ext == ".md" || ext == ".markdown") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// syncFiles publishes files and records them in the sync state. Files linking
+// to pages that are not published yet are retried once the other files have
+// been published, as long as each pass makes progress. When only files
+// linking to each other are left, they are created with placeholder content
+// so that the next pass can link them; the errors of files that still fail
+// name the placeholder page left behind.
+func syncFiles(ctx context.Context, cmd *cobra.Command, accessToken string, st *state.State, files []string, opts publishOptions, verbose bool) (int, map[string]error) {
+	published := 0
+	failed := map[string]error{}
+	pending := files
+	placeholders := map[string]string{}
+
+	for len(pending) > 0 {
+		var deferred []string
+		unresolved := map[string][]string{}
+		for _, file := range pending {
+			entry, err := publishFile(ctx, accessToken, st, file, opts)
+			var linkErr *markdown.UnresolvedLinksError
+			if errors.As(err, &linkErr) {
+				if verbose {
+					cmd.Printf("Deferring %s: %v\n", file, err)
+				}
+				failed[file] = err
+				deferred = append(deferred, file)
+				unresolved[file] = linkErr.Links
+				continue
+			}
+			var conflict *conflictError
+			if errors.As(err, &conflict) {
+				reportConflict(cmd, conflict, file, conflict.Local, opts.WriteRemote)
+			}
+			if err != nil {
+				failed[file] = err
+				continue
+			}
+
+			delete(failed, file)
+			published++
+			cmd.Printf("Published %s -> %s\n", file, entry.URL)
+		}
+
+		if len(deferred) == len(pending) {
+			created := 0
+			for _, file := range linkCycles(unresolved) {
+				if _, ok := placeholders[file]; ok {
+					continue
+				}
+				entry, err := publishPlaceholder(ctx, accessToken, st, file)
+				placeholders[file] = entry.URL
+				if err != nil {
+					failed[file] = err
+					continue
+				}
+				created++
+				if verbose {
+					cmd.Printf("Created a placeholder page for %s\n", file)
+				}
+			}
+			if created == 0 {
+				break
+			}
+		}
+		pending = deferred
+	}
+
+	for file, url := range placeholders {
+		if err, ok := failed[file]; ok && url != "" {
+			failed[file] = fmt.Errorf("%w (placeholder page left at %s)", err, url)
+		}
+	}
+
+	return published, failed
+}
+
+// linkCycles returns the files, among those with unresolved links, that are
+// linked to by files whose links only point at each other. None of those can
+// be published until the files they link to have a page.
+func linkCycles(unresolved map[string][]string) []string {
+	cyclic := map[string]bool{}
+	for file := range unresolved {
+		cyclic[absPath(file)] = true
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for file, links := range unresolved {
+			if !cyclic[absPath(file)] {
+				continue
+			}
+			for _, link := range links {
+				if !cyclic[absPath(link)] {
+					delete(cyclic, absPath(file))
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	targets := map[string]bool{}
+	for file, links := range unresolved {
+		if cyclic[absPath(file)] {
+			for _, link := range links {
+				targets[absPath(link)] = true
+			}
+		}
+	}
+
+	var files []string
+	for file := range unresolved {
+		if targets[absPath(file)] {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// absPath returns the absolute form of a path, or the path itself if it
+// cannot be made absolute
+func absPath(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		return abs
+	}
+	return file
+}
+
+// publishPlaceholder creates the page of a file with placeholder content and
+// records it in the sync state, so that other files can link to it before it
+// is published
+func publishPlaceholder(ctx context.Context, accessToken string, st *state.State, file string) (state.Entry, error) {
+	frontMatter := readFrontMatter(file)
+	title := fileTitle(file, frontMatter)
+
+	placeholder := []telegraph.Node{markdown.Element(atom.P, telegraph.Node{Text: "This page is being published."})}
+	page, err := savePage(ctx, accessToken, "", title, placeholder)
+	if err != nil {
+		return state.Entry{}, err
+	}
+	recordHash(st, page)
+
+	entry := state.Entry{
+		Path:     page.Path,
+		URL:      page.URL.String(),
+		Title:    title,
+		Tags:     frontMatter.Tags,
+		Category: frontMatter.Category,
+	}
+	if err := st.Set(file, entry); err != nil {
+		return entry, err
+	}
+	return entry, st.Save()
+}
+
+// fileTitle returns the title from the front matter, or the file name
+func fileTitle(file string, frontMatter markdown.FrontMatter) string {
+	if frontMatter.Title != "" {
+		return frontMatter.Title
+	}
+	return strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+}
+
+// publishOptions controls how files are published
+type publishOptions struct {
+	// TagFooter appends the tags of a page, linked to their tag pages
+	TagFooter bool
+	// Force overwrites pages that were changed on telegra.ph
+	Force bool
+	// WriteRemote writes the live version of conflicting pages to
+	// .remote.md files
+	WriteRemote bool
+}
+
+// publishFile creates or edits the page for a single Markdown file and
+// records it in the sync state
+func publishFile(ctx context.Context, accessToken string, st *state.State, file string, opts publishOptions) (state.Entry, error) {
+	frontMatter := readFrontMatter(file)
+
+	nodes, err := markdown.ParseFile(file, markdown.Options{
+		Links: func(target string) (string, bool) {
+			return publishedURL(st, target)
+		},
+	})
+	if err != nil {
+		return state.Entry{}, err
+	}
+	if opts.TagFooter && len(frontMatter.Tags) > 0 {
+		nodes = append(nodes, tagFooter(st, frontMatter.Tags)...)
+	}
+
+	title := fileTitle(file, frontMatter)
+
+	path := frontMatter.Path
+	if entry, ok := st.Lookup(file); ok {
+		path = entry.Path
+	}
+
+	if path != "" && !opts.Force {
+		current, err := fetchPage(ctx, path, true)
+		if err != nil {
+			return state.Entry{}, err
+		}
+		if err := checkConflict(st, current); err != nil {
+			err.(*conflictError).Local = nodes
+			return state.Entry{}, err
+		}
+	}
+
+	page, err := savePage(ctx, accessToken, path, title, nodes)
+	if err != nil {
+		return state.Entry{}, err
+	}
+	recordHash(st, page)
+
+	entry := state.Entry{
+		Path:     page.Path,
+		URL:      page.URL.String(),
+		Title:    title,
+		Tags:     frontMatter.Tags,
+		Category: frontMatter.Category,
+	}
+	if err := st.Set(file, entry); err != nil {
+		return entry, err
+	}
+
+	return entry, st.Save()
+}
+
+// publishedURL returns the telegra.ph URL of a local Markdown file, taken
+// from the sync state or the path in its front matter
+func publishedURL(st *state.State, file string) (string, bool) {
+	if entry, ok := st.Lookup(file); ok {
+		return entry.URL, true
+	}
+	if _, err := os.Stat(file); err != nil {
+		return "", false
+	}
+	if path := readFrontMatter(file).Path; path != "" {
+		return telegraphURL + path, true
+	}
+	return "", false
+}
+
+// readFrontMatter returns the front matter of a file, or an empty one if the
+// file has none
+func readFrontMatter(file string) markdown.FrontMatter {
+	f, err := os.Open(file)
+	if err != nil {
+		return markdown.FrontMatter{}
+	}
+	defer f.Close()
+
+	frontMatter, _ := markdown.ReadFrontMatter(f)
+	return frontMatter
+}
+
+// tagFooter returns a rule and a paragraph listing the tags, each linked to
+// its tag page when one has been published
+func tagFooter(st *state.State, tags []string) []telegraph.Node {
+	children := []telegraph.Node{{Text: "Tags: "}}
+	for i, tag := range tags {
+		if i > 0 {
+			children = append(children, telegraph.Node{Text: ", "})
+		}
+		if page, ok := st.TagPage(tag); ok {
+			children = append(children, markdown.Link(page.URL, telegraph.Node{Text: tag}))
+		} else {
+			children = append(children, telegraph.Node{Text: tag})
+		}
+	}
+	return []telegraph.Node{markdown.Element(atom.Hr), markdown.Element(atom.P, children...)}
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+
+	syncCmd.Flags().Bool("tag-footer", false, "Add a list of the page's tags to the end of each page")
+	syncCmd.Flags().Bool("force", false, "Overwrite pages even if they were changed on telegra.ph")
+	syncCmd.Flags().Bool("write-remote", false, "On a conflict, write the live page to a .remote.md file next to the local file")
+}
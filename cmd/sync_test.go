@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"telegraphcli/pkg/markdown"
+	"telegraphcli/pkg/state"
+)
+
+// parseLinked parses a file with its links resolved from the sync state
+func parseLinked(st *state.State, file string) error {
+	_, err := markdown.ParseFile(file, markdown.Options{
+		Links: func(target string) (string, bool) {
+			return publishedURL(st, target)
+		},
+	})
+	return err
+}
+
+func TestLinkCyclesTwoFiles(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.md")
+	b := filepath.Join(dir, "b.md")
+	c := filepath.Join(dir, "c.md")
+	d := filepath.Join(dir, "d.md")
+	files := map[string]string{
+		a: "# A\n\nSee [b](b.md#b).\n",
+		b: "# B\n\nSee [a](./a.md).\n",
+		c: "# C\n\nSee [a](a.md).\n",
+		d: "# D\n\nSee [missing](missing.md).\n",
+	}
+	for file, content := range files {
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	st := &state.State{Files: map[string]state.Entry{}}
+	unresolved := map[string][]string{}
+	for file := range files {
+		var linkErr *markdown.UnresolvedLinksError
+		if err := parseLinked(st, file); !errors.As(err, &linkErr) {
+			t.Fatalf("parsing %s: got %v, want unresolved links", file, err)
+		}
+		unresolved[file] = linkErr.Links
+	}
+
+	cyclic := linkCycles(unresolved)
+	if want := []string{a, b}; !reflect.DeepEqual(cyclic, want) {
+		t.Fatalf("linkCycles() = %v, want %v", cyclic, want)
+	}
+
+	// Placeholder pages give both files a URL
+	for _, file := range cyclic {
+		name := filepath.Base(file)
+		if err := st.Set(file, state.Entry{Path: name, URL: telegraphURL + name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{a, b, c} {
+		if err := parseLinked(st, file); err != nil {
+			t.Errorf("parsing %s after placeholders: %v", file, err)
+		}
+	}
+	if err := parseLinked(st, d); err == nil {
+		t.Errorf("parsing %s: links to a missing file resolved", d)
+	}
+}
+
+func TestLinkCyclesNone(t *testing.T) {
+	unresolved := map[string][]string{
+		"a.md": {"b.md"},
+		"b.md": {"c.md"},
+	}
+	if cyclic := linkCycles(unresolved); len(cyclic) != 0 {
+		t.Errorf("linkCycles() = %v, want none", cyclic)
+	}
+}
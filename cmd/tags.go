@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+
+	"telegraphcli/pkg/markdown"
+	"telegraphcli/pkg/state"
+	"telegraphcli/pkg/token"
+)
+
+// tagsCmd represents the tags command
+var tagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "Work with the tags of synced pages",
+	Long: `Work with the tags given in the front matter of files published with sync.
+Tags are read when a file is published and recorded in ~/.telegraphcl/sync.json.`,
+}
+
+// tagsListCmd represents the tags list command
+var tagsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List tags and their page counts",
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := state.Load()
+		if err != nil {
+			cmd.PrintErrf("Failed to load sync state: %v\n", err)
+			return
+		}
+
+		for _, t := range collectTags(st) {
+			line := t.Name + " (" + pluralPages(len(t.Entries)) + ")"
+			if page, ok := st.TagPage(t.Name); ok {
+				line += " " + page.URL
+			}
+			cmd.Println(line)
+		}
+	},
+}
+
+// tagsPublishCmd represents the tags publish command
+var tagsPublishCmd = &cobra.Command{
+	Use:   "publish",
+	Short: "Create or update one page per tag",
+	Long: `Create or update one Telegraph page per tag that lists the pages with that tag.
+The tag pages are remembered in ~/.telegraphcl/sync.json, so later runs edit the
+same pages. A tag page whose tag is no longer used is edited to say so.
+
+Run sync with --tag-footer after publishing new tags to link the pages to
+their tag pages.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+
+		titleFormat, _ := cmd.Flags().GetString("title")
+
+		accessToken, err := token.GetToken()
+		if err != nil {
+			cmd.PrintErrf("Failed to get token: %v\n", err)
+			return
+		}
+
+		st, err := state.Load()
+		if err != nil {
+			cmd.PrintErrf("Failed to load sync state: %v\n", err)
+			return
+		}
+
+		tags := collectTags(st)
+		used := map[string]bool{}
+		for _, t := range tags {
+			used[strings.ToLower(t.Name)] = true
+		}
+		// Tags that have a page but no longer any tagged files
+		for key, page := range st.TagPages {
+			if !used[key] && len(page.Tags) > 0 {
+				tags = append(tags, tagPages{Name: page.Tags[0]})
+			}
+		}
+
+		failed := 0
+		for _, t := range tags {
+			page, err := publishTagPage(ctx, accessToken, st, t, titleFormat)
+			if err != nil {
+				cmd.PrintErrf("Failed to publish tag %s: %v\n", t.Name, err)
+				failed++
+				continue
+			}
+			cmd.Printf("Published tag %s -> %s\n", t.Name, page.URL)
+		}
+
+		if err := st.Save(); err != nil {
+			cmd.PrintErrf("%v\n", err)
+			return
+		}
+		cmd.Printf("Tags published: %d, failed: %d\n", len(tags)-failed, failed)
+	},
+}
+
+// tagPages is a tag with the synced pages that have it
+type tagPages struct {
+	Name    string
+	Entries []state.Entry
+}
+
+// collectTags groups the synced pages by tag, ignoring case. Tags and their
+// pages are sorted by name.
+func collectTags(st *state.State) []tagPages {
+	byKey := map[string]*tagPages{}
+	for _, entry := range st.Files {
+		for _, tag := range entry.Tags {
+			key := strings.ToLower(tag)
+			t, ok := byKey[key]
+			if !ok {
+				t = &tagPages{Name: tag}
+				byKey[key] = t
+			}
+			t.Entries = append(t.Entries, entry)
+		}
+	}
+
+	tags := make([]tagPages, 0, len(byKey))
+	for _, t := range byKey {
+		sort.Slice(t.Entries, func(i, j int) bool {
+			return strings.ToLower(t.Entries[i].Title) < strings.ToLower(t.Entries[j].Title)
+		})
+		tags = append(tags, *t)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return strings.ToLower(tags[i].Name) < strings.ToLower(tags[j].Name)
+	})
+	return tags
+}
+
+// publishTagPage creates or edits the page of a tag and records it in the
+// sync state
+func publishTagPage(ctx context.Context, accessToken string, st *state.State, t tagPages, titleFormat string) (state.Entry, error) {
+	title := strings.ReplaceAll(titleFormat, "%s", t.Name)
+
+	var nodes []telegraph.Node
+	if len(t.Entries) == 0 {
+		nodes = []telegraph.Node{markdown.Element(atom.P, telegraph.Node{Text: "No pages are tagged " + t.Name + " anymore."})}
+	} else {
+		items := make([]telegraph.Node, 0, len(t.Entries))
+		for _, entry := range t.Entries {
+			items = append(items, markdown.Element(atom.Li, markdown.Link(entry.URL, telegraph.Node{Text: entry.Title})))
+		}
+		nodes = []telegraph.Node{markdown.Element(atom.Ul, items...)}
+	}
+
+	existing, _ := st.TagPage(t.Name)
+	page, err := savePage(ctx, accessToken, existing.Path, title, nodes)
+	if err != nil {
+		return state.Entry{}, err
+	}
+
+	entry := state.Entry{Path: page.Path, URL: page.URL.String(), Title: title, Tags: []string{t.Name}}
+	st.SetTagPage(t.Name, entry)
+	return entry, nil
+}
+
+// pluralPages returns "1 page" or "n pages"
+func pluralPages(n int) string {
+	if n == 1 {
+		return "1 page"
+	}
+	return fmt.Sprintf("%d pages", n)
+}
+
+func init() {
+	rootCmd.AddCommand(tagsCmd)
+	tagsCmd.AddCommand(tagsListCmd)
+	tagsCmd.AddCommand(tagsPublishCmd)
+
+	tagsPublishCmd.Flags().String("title", "Tag: %s", "Title of the tag pages; %s is replaced by the tag")
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+
+	"telegraphcli/pkg/config"
+	"telegraphcli/pkg/markdown"
+)
+
+// defaultTombstone is the Markdown template used in template mode when no
+// template file is configured
+const defaultTombstone = `This page was removed on {{.Date}}.
+{{if .Redirect}}
+Continue reading at [{{.Redirect}}]({{.Redirect}}).
+{{end}}`
+
+// tombstoneData holds the variables available to tombstone templates
+type tombstoneData struct {
+	Title    string
+	Path     string
+	URL      string
+	Author   string
+	Redirect string
+	Date     string
+}
+
+// deleteOptions returns the delete settings from the config file overridden
+// by the flags given on the command line
+func deleteOptions(cmd *cobra.Command) (config.Delete, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return config.Delete{}, err
+	}
+	opts := cfg.Delete
+
+	flags := cmd.Flags()
+	if flags.Changed("mode") {
+		opts.Mode, _ = flags.GetString("mode")
+	}
+	if flags.Changed("template") {
+		opts.Template, _ = flags.GetString("template")
+		if !flags.Changed("mode") {
+			opts.Mode = "template"
+		}
+	}
+	if flags.Changed("title") {
+		opts.Title, _ = flags.GetString("title")
+	}
+	if flags.Changed("redirect") {
+		opts.Redirect, _ = flags.GetString("redirect")
+	}
+	if flags.Changed("moved-to") {
+		opts.Redirect, _ = flags.GetString("moved-to")
+		opts.Mode = "moved"
+	}
+	return opts, nil
+}
+
+// tombstone returns the title and content a deleted page is replaced with
+func tombstone(opts config.Delete, page *telegraph.Page) (string, []telegraph.Node, error) {
+	data := tombstoneData{
+		Title:    titleOf(page),
+		Path:     page.Path,
+		URL:      page.URL.String(),
+		Author:   authorOf(page),
+		Redirect: movedURL(opts.Redirect),
+		Date:     time.Now().Format("2 January 2006"),
+	}
+
+	var defaultTitle string
+	var nodes []telegraph.Node
+	switch opts.Mode {
+	case "", "minimal":
+		defaultTitle = "Deleted"
+		nodes = []telegraph.Node{markdown.Element(atom.P, telegraph.Node{Text: "[deleted]"})}
+	case "moved":
+		if data.Redirect == "" {
+			return "", nil, fmt.Errorf("moved mode needs a replacement page, set it with --moved-to")
+		}
+		defaultTitle = "Moved: {{.Title}}"
+		nodes = []telegraph.Node{markdown.Element(atom.P,
+			telegraph.Node{Text: "This page has moved to "},
+			markdown.Link(data.Redirect, telegraph.Node{Text: data.Redirect}),
+			telegraph.Node{Text: "."},
+		)}
+	case "template":
+		source := defaultTombstone
+		if opts.Template != "" {
+			b, err := os.ReadFile(config.ExpandHome(opts.Template))
+			if err != nil {
+				return "", nil, fmt.Errorf("failed to read template: %v", err)
+			}
+			source = string(b)
+		}
+		content, err := executeTemplate("tombstone", source, data)
+		if err != nil {
+			return "", nil, err
+		}
+		nodes, err = markdown.ParseReader(strings.NewReader(content), markdown.Options{})
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to parse template output: %v", err)
+		}
+		if len(nodes) == 0 {
+			return "", nil, fmt.Errorf("template %s renders no content", opts.Template)
+		}
+		defaultTitle = "Deleted"
+	default:
+		return "", nil, fmt.Errorf("unknown delete mode %q, use minimal, template or moved", opts.Mode)
+	}
+
+	titleTemplate := opts.Title
+	if titleTemplate == "" {
+		titleTemplate = defaultTitle
+	}
+	title, err := executeTemplate("title", titleTemplate, data)
+	if err != nil {
+		return "", nil, err
+	}
+	title = strings.TrimSpace(title)
+	if title == "" {
+		title = "Deleted"
+	}
+	return title, nodes, nil
+}
+
+// deletePage keeps a page fetched with its content in the history and the
+// trash, then overwrites it with a tombstone. It returns the tombstone page
+// with its content, whose hash callers record in the sync state.
+func deletePage(ctx context.Context, accessToken string, page *telegraph.Page, title string, nodes []telegraph.Node) (*telegraph.Page, error) {
+	pageTitle, err := telegraph.NewTitle(title)
+	if err != nil {
+		return nil, fmt.Errorf("invalid title: %v", err)
+	}
+
+	if err := snapshotPage(page, "delete"); err != nil {
+		return nil, fmt.Errorf("failed to snapshot page: %v", err)
+	}
+	if err := trashPage(page); err != nil {
+		return nil, fmt.Errorf("failed to move page to the trash: %v", err)
+	}
+
+	// Clear the author fields by sending them empty rather than leaving
+	// them out, which would keep the current values
+	clearedName, _ := telegraph.NewAuthorName("")
+	editPage := telegraph.EditPage{
+		AccessToken:   accessToken,
+		Path:          page.Path,
+		Title:         *pageTitle,
+		Content:       nodes,
+		AuthorName:    clearedName,
+		AuthorURL:     telegraph.NewURL(&url.URL{}),
+		ReturnContent: true,
+	}
+	var deleted *telegraph.Page
+	err = retry(func() error {
+		var e error
+		deleted, e = editPage.Do(ctx, newAPIClient())
+		return e
+	}, 3)
+	return deleted, err
+}
+
+// executeTemplate renders a text template with the given variables
+func executeTemplate(name, source string, data interface{}) (string, error) {
+	tmpl, err := template.New(name).Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %v", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("invalid %s template: %v", name, err)
+	}
+	return buf.String(), nil
+}
+
+// movedURL turns a page path into its telegra.ph URL and leaves full URLs
+// unchanged
+func movedURL(target string) string {
+	if target == "" {
+		return ""
+	}
+	if u, err := url.Parse(target); err == nil && u.Scheme != "" {
+		return target
+	}
+	return telegraphURL + strings.TrimPrefix(target, "/")
+}
@@ -5,6 +5,7 @@ go 1.24.3
 require (
 	github.com/cenkalti/backoff/v4 v4.3.0
 	github.com/spf13/cobra v1.9.1
+	github.com/yuin/goldmark v1.7.13
 	golang.org/x/net v0.40.0
 	gopkg.in/yaml.v3 v3.0.1
 	source.toby3d.me/toby3d/telegraph/v2 v2.2.0
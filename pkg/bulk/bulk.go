@@ -0,0 +1,138 @@
+package bulk
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Result is the outcome of processing one item
+type Result struct {
+	Item    string
+	Detail  string
+	Err     error
+	Elapsed time.Duration
+}
+
+// Func processes one item and returns a short description of what it did
+type Func func(ctx context.Context, item string) (string, error)
+
+// Run calls fn for every item on at most workers goroutines. A failing item
+// does not stop the others. Results are returned in the order of items.
+// progress, when not nil, is called once per finished item from a single
+// goroutine.
+func Run(ctx context.Context, items []string, workers int, fn Func, progress func(Result)) []Result {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(items) {
+		workers = len(items)
+	}
+
+	results := make([]Result, len(items))
+	jobs := make(chan int)
+	done := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				start := time.Now()
+				r := Result{Item: items[j]}
+				if err := ctx.Err(); err != nil {
+					r.Err = err
+				} else {
+					r.Detail, r.Err = fn(ctx, items[j])
+				}
+				r.Elapsed = time.Since(start)
+				results[j] = r
+				done <- j
+			}
+		}()
+	}
+
+	go func() {
+		for j := range items {
+			jobs <- j
+		}
+		close(jobs)
+		wg.Wait()
+		close(done)
+	}()
+
+	for j := range done {
+		if progress != nil {
+			progress(results[j])
+		}
+	}
+	return results
+}
+
+// Failed returns the number of results with an error
+func Failed(results []Result) int {
+	n := 0
+	for _, r := range results {
+		if r.Err != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteSummary writes a table with the status of every item followed by a
+// line with the totals
+func WriteSummary(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tITEM\tDETAIL")
+	for _, r := range results {
+		status, detail := "ok", r.Detail
+		if r.Err != nil {
+			status, detail = "failed", r.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", status, r.Item, detail)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	failed := Failed(results)
+	_, err := fmt.Fprintf(w, "%d succeeded, %d failed\n", len(results)-failed, failed)
+	return err
+}
+
+// Progress shows a single updating status line on a terminal
+type Progress struct {
+	w      io.Writer
+	label  string
+	total  int
+	done   int
+	failed int
+}
+
+// NewProgress returns a progress display for total items written to w
+func NewProgress(w io.Writer, label string, total int) *Progress {
+	return &Progress{w: w, label: label, total: total}
+}
+
+// Update records a finished item and redraws the status line
+func (p *Progress) Update(r Result) {
+	p.done++
+	if r.Err != nil {
+		p.failed++
+	}
+	fmt.Fprintf(p.w, "\r\033[K%s [%d/%d]", p.label, p.done, p.total)
+	if p.failed > 0 {
+		fmt.Fprintf(p.w, " %d failed", p.failed)
+	}
+	fmt.Fprintf(p.w, " %s", r.Item)
+}
+
+// Finish clears the status line
+func (p *Progress) Finish() {
+	fmt.Fprint(p.w, "\r\033[K")
+}
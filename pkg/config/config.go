@@ -0,0 +1,166 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"telegraphcli/pkg/token"
+)
+
+// ConfigFile is the name of the configuration file
+const ConfigFile = "config.yaml"
+
+// Config holds the settings read from ~/.telegraphcl/config.yaml
+type Config struct {
+	Delete   Delete             `yaml:"delete"`
+	Profiles map[string]Profile `yaml:"profiles"`
+	Serve    Serve              `yaml:"serve"`
+}
+
+// Delete configures the tombstone page delete leaves behind
+type Delete struct {
+	// Mode is minimal, template or moved
+	Mode string `yaml:"mode"`
+	// Template is a Markdown file rendered as the tombstone in template mode
+	Template string `yaml:"template"`
+	// Title is the title of the tombstone, a template like the content
+	Title string `yaml:"title"`
+	// Redirect is the URL made available to templates as {{.Redirect}}
+	Redirect string `yaml:"redirect"`
+}
+
+// Profile is a named Telegraph account, given by its access token or a
+// file holding it
+type Profile struct {
+	Token     string `yaml:"token"`
+	TokenFile string `yaml:"token_file"`
+}
+
+// Serve configures the servers started by serve
+type Serve struct {
+	Metrics Metrics `yaml:"metrics"`
+	API     API     `yaml:"api"`
+	Webhook Webhook `yaml:"webhook"`
+}
+
+// Metrics configures the Prometheus exporter of serve metrics
+type Metrics struct {
+	// Listen is the address to listen on, such as :9300
+	Listen string `yaml:"listen"`
+	// Interval is how often page views are refreshed, such as 5m
+	Interval string `yaml:"interval"`
+	// Match, Author, MinViews, Tag and Category select the exported pages
+	// like the page list flags of the same names
+	Match    string `yaml:"match"`
+	Author   string `yaml:"author"`
+	MinViews int    `yaml:"min_views"`
+	Tag      string `yaml:"tag"`
+	Category string `yaml:"category"`
+}
+
+// API configures the REST API of serve api
+type API struct {
+	// Listen is the address to listen on, such as 127.0.0.1:9301
+	Listen string `yaml:"listen"`
+	// Keys are the API keys accepted from clients
+	Keys []APIKey `yaml:"keys"`
+}
+
+// APIKey is the key of one API client and the profile it publishes as
+type APIKey struct {
+	Name    string `yaml:"name"`
+	Key     string `yaml:"key"`
+	Profile string `yaml:"profile"`
+}
+
+// Webhook configures the push receiver of serve webhook
+type Webhook struct {
+	// Listen is the address to listen on, such as :9302
+	Listen string `yaml:"listen"`
+	// Secret is the shared secret deliveries are signed with
+	Secret string `yaml:"secret"`
+	// Dir is the local checkout, or the directory payload files are
+	// written to
+	Dir string `yaml:"dir"`
+	// Branch is the branch that is published, main by default
+	Branch string `yaml:"branch"`
+	// Prefix limits publishing to files below this repository directory
+	Prefix string `yaml:"prefix"`
+	// Pull runs git pull in Dir before publishing
+	Pull bool `yaml:"pull"`
+	// Profile is the account pages are published as
+	Profile string `yaml:"profile"`
+}
+
+// GetConfigPath returns the path to the configuration file
+func GetConfigPath() (string, error) {
+	tokenPath, err := token.GetTokenPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(tokenPath), ConfigFile), nil
+}
+
+// Load reads the configuration, returning an empty one if the file does not
+// exist
+func Load() (*Config, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Config{}
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %v", err)
+	}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", configPath, err)
+	}
+
+	return c, nil
+}
+
+// ProfileToken returns the access token of a profile. The empty name and
+// "default", unless configured, use the token of the current user.
+func (c *Config) ProfileToken(name string) (string, error) {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		if name == "" || name == "default" {
+			return token.GetToken()
+		}
+		return "", fmt.Errorf("unknown profile %q", name)
+	}
+
+	if profile.Token != "" {
+		return profile.Token, nil
+	}
+	if profile.TokenFile == "" {
+		return "", fmt.Errorf("profile %q has no token or token_file", name)
+	}
+	data, err := os.ReadFile(ExpandHome(profile.TokenFile))
+	if err != nil {
+		return "", fmt.Errorf("failed to read token of profile %q: %v", name, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ExpandHome replaces a leading ~ in a path with the home directory
+func ExpandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
@@ -0,0 +1,306 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Kind is the type of an edit
+type Kind int
+
+const (
+	// Equal keeps a line
+	Equal Kind = iota
+	// Delete removes a line of the old text
+	Delete
+	// Insert adds a line of the new text
+	Insert
+)
+
+// Edit is one step of an edit script
+type Edit struct {
+	Kind Kind
+	Text string
+}
+
+// Diff returns a shortest edit script turning a into b, computed with the
+// Myers algorithm
+func Diff(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	// trace[d] holds v[-d-1..d+1] as it was before step d
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack walks the trace from the end to recover the edit script
+func backtrack(trace [][]int, a, b []string) []Edit {
+	var edits []Edit
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, Edit{Kind: Equal, Text: a[x]})
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, Edit{Kind: Insert, Text: b[prevY]})
+			} else {
+				edits = append(edits, Edit{Kind: Delete, Text: a[prevX]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// Changed reports whether an edit script changes anything
+func Changed(edits []Edit) bool {
+	for _, e := range edits {
+		if e.Kind != Equal {
+			return true
+		}
+	}
+	return false
+}
+
+// Hunk is a group of changes with surrounding context lines
+type Hunk struct {
+	AStart, ALines int
+	BStart, BLines int
+	Edits          []Edit
+}
+
+// Hunks groups an edit script into hunks with the given number of context
+// lines. Changes closer than twice the context share a hunk.
+func Hunks(edits []Edit, context int) []Hunk {
+	keep := make([]bool, len(edits))
+	last := -1 << 30
+	for i, e := range edits {
+		if e.Kind != Equal {
+			last = i
+		}
+		keep[i] = i-last <= context
+	}
+	last = 1 << 30
+	for i := len(edits) - 1; i >= 0; i-- {
+		if edits[i].Kind != Equal {
+			last = i
+		}
+		keep[i] = keep[i] || last-i <= context
+	}
+
+	var hunks []Hunk
+	var h *Hunk
+	aLine, bLine := 0, 0
+	for i, e := range edits {
+		if keep[i] {
+			if h == nil {
+				hunks = append(hunks, Hunk{AStart: aLine + 1, BStart: bLine + 1})
+				h = &hunks[len(hunks)-1]
+			}
+			h.Edits = append(h.Edits, e)
+			if e.Kind != Insert {
+				h.ALines++
+			}
+			if e.Kind != Delete {
+				h.BLines++
+			}
+		} else {
+			h = nil
+		}
+		if e.Kind != Insert {
+			aLine++
+		}
+		if e.Kind != Delete {
+			bLine++
+		}
+	}
+
+	// An empty range is numbered by the line before it
+	for i := range hunks {
+		if hunks[i].ALines == 0 {
+			hunks[i].AStart--
+		}
+		if hunks[i].BLines == 0 {
+			hunks[i].BStart--
+		}
+	}
+	return hunks
+}
+
+// Options controls how a diff is printed
+type Options struct {
+	// Context is the number of unchanged lines shown around changes
+	Context int
+	// Color marks changes with ANSI colours
+	Color bool
+	// Words shows changed lines as a word-level diff instead of whole lines
+	Words bool
+}
+
+const (
+	colorReset = "\x1b[0m"
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+	colorBold  = "\x1b[1m"
+)
+
+// Unified formats the differences between a and b as a unified diff. It
+// returns an empty string when they are equal.
+func Unified(aName, bName string, a, b []string, opts Options) string {
+	edits := Diff(a, b)
+	if !Changed(edits) {
+		return ""
+	}
+
+	var out strings.Builder
+	paint := func(color, text string) string {
+		if !opts.Color {
+			return text
+		}
+		return color + text + colorReset
+	}
+
+	out.WriteString(paint(colorBold, "--- "+aName) + "\n")
+	out.WriteString(paint(colorBold, "+++ "+bName) + "\n")
+	for _, h := range Hunks(edits, opts.Context) {
+		out.WriteString(paint(colorCyan, fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.AStart, h.ALines, h.BStart, h.BLines)) + "\n")
+
+		for i := 0; i < len(h.Edits); {
+			e := h.Edits[i]
+			if e.Kind == Equal {
+				out.WriteString(" " + e.Text + "\n")
+				i++
+				continue
+			}
+
+			// Collect the run of changed lines
+			var deleted, inserted []string
+			for ; i < len(h.Edits) && h.Edits[i].Kind != Equal; i++ {
+				if h.Edits[i].Kind == Delete {
+					deleted = append(deleted, h.Edits[i].Text)
+				} else {
+					inserted = append(inserted, h.Edits[i].Text)
+				}
+			}
+
+			if opts.Words {
+				out.WriteString(wordDiff(strings.Join(deleted, "\n"), strings.Join(inserted, "\n"), paint))
+				continue
+			}
+			for _, line := range deleted {
+				out.WriteString(paint(colorRed, "-"+line) + "\n")
+			}
+			for _, line := range inserted {
+				out.WriteString(paint(colorGreen, "+"+line) + "\n")
+			}
+		}
+	}
+	return out.String()
+}
+
+// wordDiff shows the change from a to b inline, marking removed words as
+// [-word-] and added words as {+word+}, or in colour
+func wordDiff(a, b string, paint func(color, text string) string) string {
+	var out strings.Builder
+	for _, e := range Diff(Words(a), Words(b)) {
+		switch e.Kind {
+		case Equal:
+			out.WriteString(e.Text)
+		case Delete:
+			out.WriteString(paint(colorRed, "[-"+e.Text+"-]"))
+		case Insert:
+			out.WriteString(paint(colorGreen, "{+"+e.Text+"+}"))
+		}
+	}
+	return "~" + strings.ReplaceAll(out.String(), "\n", "\n~") + "\n"
+}
+
+// Words splits text into words, runs of whitespace and single punctuation
+// characters, so that joining them gives back the text
+func Words(text string) []string {
+	var words []string
+	start := -1
+	class := func(r rune) int {
+		switch {
+		case unicode.IsSpace(r):
+			return 1
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			return 2
+		}
+		return 3
+	}
+
+	prev := 0
+	for i, r := range text {
+		c := class(r)
+		if start >= 0 && (c != prev || c == 3) {
+			words = append(words, text[start:i])
+			start = -1
+		}
+		if start < 0 {
+			start = i
+		}
+		prev = c
+	}
+	if start >= 0 {
+		words = append(words, text[start:])
+	}
+	return words
+}
+
+// Lines splits text into lines without their line endings
+func Lines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
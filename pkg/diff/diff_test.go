@@ -0,0 +1,129 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// lines splits a space separated list of lines
+func lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Fields(s)
+}
+
+// apply returns the old and new text described by an edit script
+func apply(edits []Edit) (a, b []string) {
+	for _, e := range edits {
+		if e.Kind != Insert {
+			a = append(a, e.Text)
+		}
+		if e.Kind != Delete {
+			b = append(b, e.Text)
+		}
+	}
+	return a, b
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		changes int
+	}{
+		{"", "", 0},
+		{"a b c", "a b c", 0},
+		{"", "a b", 2},
+		{"a b", "", 2},
+		{"a b c", "a x c", 2},
+		{"a b c", "x a b c", 1},
+		{"a b c", "a b c x", 1},
+		{"a b c", "b c", 1},
+		{"a b c", "a b", 1},
+		{"a b c a b b a", "c b a b a c", 5},
+		{"a b c d e f", "f e d c b a", 10},
+		{"x a x b x", "a b", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			edits := Diff(lines(tt.a), lines(tt.b))
+			a, b := apply(edits)
+			if !reflect.DeepEqual(a, lines(tt.a)) || !reflect.DeepEqual(b, lines(tt.b)) {
+				t.Fatalf("Diff(%q, %q) = %v turns %q into %q", tt.a, tt.b, edits, a, b)
+			}
+			changes := 0
+			for _, e := range edits {
+				if e.Kind != Equal {
+					changes++
+				}
+			}
+			if changes != tt.changes {
+				t.Errorf("Diff(%q, %q) has %d changes, want %d", tt.a, tt.b, changes, tt.changes)
+			}
+			if Changed(edits) != (tt.changes > 0) {
+				t.Errorf("Changed(Diff(%q, %q)) = %v", tt.a, tt.b, Changed(edits))
+			}
+		})
+	}
+}
+
+func TestHunks(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		context int
+		want    []string
+	}{
+		{"no changes", "a b c", "a b c", 3, nil},
+		{"append", "a b c", "a b c d", 3, []string{"-1,3 +1,4"}},
+		{"prepend", "a b c", "x a b c", 3, []string{"-1,3 +1,4"}},
+		{"into empty", "", "a", 3, []string{"-0,0 +1,1"}},
+		{"delete all", "a b", "", 3, []string{"-1,2 +0,0"}},
+		{"insert without context", "a b", "a x b", 0, []string{"-1,0 +2,1"}},
+		{"delete without context", "a x b", "a b", 0, []string{"-2,1 +1,0"}},
+		{"change in the middle", "1 2 3 4 5 6 7 8 9", "1 2 3 4 x 6 7 8 9", 1, []string{"-4,3 +4,3"}},
+		{"context at the start", "1 2 3 4 5", "x 2 3 4 5", 2, []string{"-1,3 +1,3"}},
+		{"context at the end", "1 2 3 4 5", "1 2 3 4 x", 2, []string{"-3,3 +3,3"}},
+		{"gap of twice the context", "1 2 3 4 5 6 7 8", "1 x 3 4 5 6 y 8", 2, []string{"-1,8 +1,8"}},
+		{"gap over twice the context", "1 2 3 4 5 6 7 8 9", "1 x 3 4 5 6 7 y 9", 2, []string{"-1,4 +1,4", "-6,4 +6,4"}},
+		{"line counts differ", "1 2 3 4 5 6 7 8 9", "1 2 x y 4 5 6 7 9", 1, []string{"-2,3 +2,4", "-7,3 +8,2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, h := range Hunks(Diff(lines(tt.a), lines(tt.b)), tt.context) {
+				got = append(got, fmt.Sprintf("-%d,%d +%d,%d", h.AStart, h.ALines, h.BStart, h.BLines))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Hunks(%q, %q, %d) = %q, want %q", tt.a, tt.b, tt.context, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnified(t *testing.T) {
+	got := Unified("a", "b", Lines("one\ntwo\nthree\n"), Lines("one\n2\nthree\n"), Options{Context: 1})
+	want := "--- a\n+++ b\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n"
+	if got != want {
+		t.Errorf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+	if got := Unified("a", "b", []string{"x"}, []string{"x"}, Options{}); got != "" {
+		t.Errorf("Unified() of equal texts = %q, want \"\"", got)
+	}
+}
+
+func TestWords(t *testing.T) {
+	for _, text := range []string{"", "one", "Hello, world!", "a  b\n\tc_d (e)"} {
+		words := Words(text)
+		if got := strings.Join(words, ""); got != text {
+			t.Errorf("Words(%q) = %q joins to %q", text, words, got)
+		}
+	}
+	if got, want := Words("Hello, world!"), []string{"Hello", ",", " ", "world", "!"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Words() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,283 @@
+package epub
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ValidationError lists the structural problems found in an EPUB
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid EPUB:\n  %s", strings.Join(e.Problems, "\n  "))
+}
+
+// opfPackage is the subset of the package document that is checked
+type opfPackage struct {
+	Version  string `xml:"version,attr"`
+	UniqueID string `xml:"unique-identifier,attr"`
+	Metadata struct {
+		Identifiers []struct {
+			ID    string `xml:"id,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"http://purl.org/dc/elements/1.1/ identifier"`
+		Titles    []string `xml:"http://purl.org/dc/elements/1.1/ title"`
+		Languages []string `xml:"http://purl.org/dc/elements/1.1/ language"`
+		Metas     []struct {
+			Property string `xml:"property,attr"`
+			Value    string `xml:",chardata"`
+		} `xml:"meta"`
+	} `xml:"metadata"`
+	Items []struct {
+		ID         string `xml:"id,attr"`
+		Href       string `xml:"href,attr"`
+		MediaType  string `xml:"media-type,attr"`
+		Properties string `xml:"properties,attr"`
+	} `xml:"manifest>item"`
+	ItemRefs []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// Validate checks the structure of an EPUB 3 container: the mimetype file,
+// container.xml, the required package metadata, that every manifest item
+// exists and every file is in the manifest, that the spine and navigation
+// document are present, that XHTML documents are well-formed with unique ids
+// and that links and images in them point at files in the publication.
+func Validate(r io.ReaderAt, size int64) error {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("not a zip archive: %v", err)
+	}
+
+	v := &validator{files: map[string]*zip.File{}}
+	for _, f := range z.File {
+		v.files[f.Name] = f
+	}
+
+	if len(z.File) == 0 || z.File[0].Name != "mimetype" {
+		v.problem("mimetype must be the first file in the archive")
+	} else if z.File[0].Method != zip.Store {
+		v.problem("mimetype must be stored uncompressed")
+	} else if data, err := v.read("mimetype"); err == nil && string(data) != MimeType {
+		v.problem("mimetype must contain %q", MimeType)
+	}
+
+	opfPath := v.rootfile()
+	if opfPath != "" {
+		v.checkPackage(opfPath)
+	}
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+// validator collects the problems found while checking an archive
+type validator struct {
+	files    map[string]*zip.File
+	problems []string
+}
+
+func (v *validator) problem(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+// read returns the content of a file in the archive
+func (v *validator) read(name string) ([]byte, error) {
+	f, ok := v.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%s is missing", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// rootfile returns the path of the package document named in container.xml
+func (v *validator) rootfile() string {
+	data, err := v.read("META-INF/container.xml")
+	if err != nil {
+		v.problem("%v", err)
+		return ""
+	}
+
+	var container struct {
+		Rootfiles []struct {
+			FullPath  string `xml:"full-path,attr"`
+			MediaType string `xml:"media-type,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := xml.Unmarshal(data, &container); err != nil {
+		v.problem("META-INF/container.xml: %v", err)
+		return ""
+	}
+	for _, rf := range container.Rootfiles {
+		if rf.MediaType == "application/oebps-package+xml" {
+			if _, ok := v.files[rf.FullPath]; !ok {
+				v.problem("package document %s is missing", rf.FullPath)
+				return ""
+			}
+			return rf.FullPath
+		}
+	}
+	v.problem("META-INF/container.xml has no package document rootfile")
+	return ""
+}
+
+// checkPackage checks the package document and the files it lists
+func (v *validator) checkPackage(opfPath string) {
+	data, err := v.read(opfPath)
+	if err != nil {
+		v.problem("%v", err)
+		return
+	}
+	var pkg opfPackage
+	if err := xml.Unmarshal(data, &pkg); err != nil {
+		v.problem("%s: %v", opfPath, err)
+		return
+	}
+
+	if !strings.HasPrefix(pkg.Version, "3.") {
+		v.problem("%s: package version must be 3.x, got %q", opfPath, pkg.Version)
+	}
+	hasID := false
+	for _, id := range pkg.Metadata.Identifiers {
+		if id.ID == pkg.UniqueID && strings.TrimSpace(id.Value) != "" {
+			hasID = true
+		}
+	}
+	if !hasID {
+		v.problem("%s: no dc:identifier matches unique-identifier %q", opfPath, pkg.UniqueID)
+	}
+	if len(pkg.Metadata.Titles) == 0 || strings.TrimSpace(pkg.Metadata.Titles[0]) == "" {
+		v.problem("%s: dc:title is missing", opfPath)
+	}
+	if len(pkg.Metadata.Languages) == 0 || strings.TrimSpace(pkg.Metadata.Languages[0]) == "" {
+		v.problem("%s: dc:language is missing", opfPath)
+	}
+	hasModified := false
+	for _, meta := range pkg.Metadata.Metas {
+		if meta.Property == "dcterms:modified" && strings.TrimSpace(meta.Value) != "" {
+			hasModified = true
+		}
+	}
+	if !hasModified {
+		v.problem("%s: dcterms:modified is missing", opfPath)
+	}
+
+	base := path.Dir(opfPath)
+	ids := map[string]bool{}
+	listed := map[string]bool{opfPath: true}
+	var documents []string
+	nav := 0
+	for _, item := range pkg.Items {
+		if ids[item.ID] {
+			v.problem("%s: duplicate manifest id %q", opfPath, item.ID)
+		}
+		ids[item.ID] = true
+
+		name := path.Join(base, item.Href)
+		listed[name] = true
+		if _, ok := v.files[name]; !ok {
+			v.problem("%s: manifest item %s is missing from the archive", opfPath, item.Href)
+			continue
+		}
+		if item.MediaType == "application/xhtml+xml" {
+			documents = append(documents, name)
+		}
+		if strings.Contains(" "+item.Properties+" ", " nav ") {
+			nav++
+		}
+	}
+	if nav != 1 {
+		v.problem("%s: the manifest must have exactly one nav item, found %d", opfPath, nav)
+	}
+
+	if len(pkg.ItemRefs) == 0 {
+		v.problem("%s: the spine is empty", opfPath)
+	}
+	for _, ref := range pkg.ItemRefs {
+		if !ids[ref.IDRef] {
+			v.problem("%s: spine itemref %q is not in the manifest", opfPath, ref.IDRef)
+		}
+	}
+
+	for name := range v.files {
+		if name == "mimetype" || strings.HasPrefix(name, "META-INF/") || strings.HasSuffix(name, "/") {
+			continue
+		}
+		if !listed[name] {
+			v.problem("%s is not listed in the manifest", name)
+		}
+	}
+
+	for _, doc := range documents {
+		v.checkDocument(doc, listed)
+	}
+}
+
+// checkDocument checks that an XHTML document is well-formed, that its ids
+// are unique and that its local links and images point at files in the
+// manifest
+func (v *validator) checkDocument(name string, listed map[string]bool) {
+	data, err := v.read(name)
+	if err != nil {
+		v.problem("%v", err)
+		return
+	}
+
+	d := xml.NewDecoder(strings.NewReader(string(data)))
+	d.Strict = true
+	d.Entity = map[string]string{}
+	root := true
+	ids := map[string]bool{}
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			v.problem("%s: %v", name, err)
+			return
+		}
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if root {
+			if el.Name.Local != "html" || el.Name.Space != "http://www.w3.org/1999/xhtml" {
+				v.problem("%s: root element must be html in the XHTML namespace", name)
+			}
+			root = false
+		}
+		for _, attr := range el.Attr {
+			if attr.Name.Local == "id" && attr.Name.Space == "" {
+				if ids[attr.Value] {
+					v.problem("%s: duplicate id %q", name, attr.Value)
+				}
+				ids[attr.Value] = true
+			}
+			if attr.Name.Local != "href" && attr.Name.Local != "src" {
+				continue
+			}
+			ref := strings.SplitN(attr.Value, "#", 2)[0]
+			if ref == "" || strings.Contains(ref, ":") {
+				continue
+			}
+			if !listed[path.Join(path.Dir(name), ref)] {
+				v.problem("%s: %s %q is not in the publication", name, attr.Name.Local, attr.Value)
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package epub
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateDuplicateIDs(t *testing.T) {
+	book := func(body string) []byte {
+		var buf bytes.Buffer
+		err := Write(&buf, Book{
+			Title:      "Book",
+			Language:   "en",
+			Identifier: NewIdentifier(),
+			Modified:   time.Now(),
+			Chapters:   []Chapter{{Title: "One", FileName: "chapter-1.xhtml", Body: body}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	unique := book(`<h3 id="Intro">Intro</h3><h4 id="Intro-2">Intro</h4>`)
+	if err := Validate(bytes.NewReader(unique), int64(len(unique))); err != nil {
+		t.Errorf("Validate() with unique ids: %v", err)
+	}
+
+	duplicate := book(`<h3 id="Intro">Intro</h3><h4 id="Intro">Intro</h4>`)
+	err := Validate(bytes.NewReader(duplicate), int64(len(duplicate)))
+	if err == nil || !strings.Contains(err.Error(), `duplicate id "Intro"`) {
+		t.Errorf("Validate() with duplicate ids = %v, want a duplicate id problem", err)
+	}
+}
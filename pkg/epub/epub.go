@@ -0,0 +1,194 @@
+package epub
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// MimeType is the content of the mimetype file of every EPUB
+const MimeType = "application/epub+zip"
+
+// contentDir holds the package document and all publication resources
+const contentDir = "OEBPS/"
+
+// Book holds the metadata and content of an EPUB 3 publication
+type Book struct {
+	Title      string
+	Author     string
+	Language   string
+	Identifier string
+	Modified   time.Time
+	Chapters   []Chapter
+	Images     []Image
+}
+
+// Chapter is a single XHTML content document
+type Chapter struct {
+	Title string
+	// FileName is the name of the chapter file, e.g. chapter-1.xhtml
+	FileName string
+	// Body is the XHTML markup placed inside the body element
+	Body string
+}
+
+// Image is an image resource referenced by the chapters
+type Image struct {
+	// FileName is the path of the image relative to the chapters,
+	// e.g. images/1.jpg
+	FileName  string
+	MediaType string
+	Data      []byte
+}
+
+// MediaTypes lists the image media types EPUB readers must support
+var MediaTypes = map[string]string{
+	"image/gif":     ".gif",
+	"image/jpeg":    ".jpg",
+	"image/png":     ".png",
+	"image/svg+xml": ".svg",
+	"image/webp":    ".webp",
+}
+
+// NewIdentifier returns a random urn:uuid identifier for a book
+func NewIdentifier() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// Write writes the book as an EPUB 3 container
+func Write(w io.Writer, b Book) error {
+	z := zip.NewWriter(w)
+
+	// The mimetype file must come first and be stored uncompressed
+	f, err := z.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, MimeType); err != nil {
+		return err
+	}
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"META-INF/container.xml", []byte(containerXML)},
+		{contentDir + "content.opf", []byte(packageDocument(b))},
+		{contentDir + "nav.xhtml", []byte(navDocument(b))},
+		{contentDir + "style.css", []byte(stylesheet)},
+	}
+	for _, c := range b.Chapters {
+		files = append(files, struct {
+			name string
+			data []byte
+		}{contentDir + c.FileName, []byte(chapterDocument(b, c))})
+	}
+	for _, img := range b.Images {
+		files = append(files, struct {
+			name string
+			data []byte
+		}{contentDir + img.FileName, img.Data})
+	}
+
+	for _, file := range files {
+		f, err := z.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(file.data); err != nil {
+			return err
+		}
+	}
+
+	return z.Close()
+}
+
+const containerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+// packageDocument renders content.opf with the metadata, manifest and spine
+func packageDocument(b Book) string {
+	var s strings.Builder
+	s.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	s.WriteString(`<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="` + esc(b.Language) + `">` + "\n")
+	s.WriteString(`  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">` + "\n")
+	s.WriteString(`    <dc:identifier id="book-id">` + esc(b.Identifier) + "</dc:identifier>\n")
+	s.WriteString("    <dc:title>" + esc(b.Title) + "</dc:title>\n")
+	s.WriteString("    <dc:language>" + esc(b.Language) + "</dc:language>\n")
+	if b.Author != "" {
+		s.WriteString("    <dc:creator>" + esc(b.Author) + "</dc:creator>\n")
+	}
+	s.WriteString(`    <meta property="dcterms:modified">` + b.Modified.UTC().Format("2006-01-02T15:04:05Z") + "</meta>\n")
+	s.WriteString("  </metadata>\n  <manifest>\n")
+	s.WriteString(`    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>` + "\n")
+	s.WriteString(`    <item id="style" href="style.css" media-type="text/css"/>` + "\n")
+	for i, c := range b.Chapters {
+		s.WriteString(fmt.Sprintf(`    <item id="chapter-%d" href="%s" media-type="application/xhtml+xml"/>`+"\n", i+1, esc(c.FileName)))
+	}
+	for i, img := range b.Images {
+		s.WriteString(fmt.Sprintf(`    <item id="image-%d" href="%s" media-type="%s"/>`+"\n", i+1, esc(img.FileName), esc(img.MediaType)))
+	}
+	s.WriteString("  </manifest>\n  <spine>\n")
+	for i := range b.Chapters {
+		s.WriteString(fmt.Sprintf(`    <itemref idref="chapter-%d"/>`+"\n", i+1))
+	}
+	s.WriteString("  </spine>\n</package>\n")
+	return s.String()
+}
+
+// navDocument renders the navigation document listing the chapters
+func navDocument(b Book) string {
+	var s strings.Builder
+	s.WriteString(xhtmlHeader(b.Language, b.Title))
+	s.WriteString(`<nav epub:type="toc" id="toc">` + "\n<h1>" + esc(b.Title) + "</h1>\n<ol>\n")
+	for _, c := range b.Chapters {
+		s.WriteString(`<li><a href="` + esc(c.FileName) + `">` + esc(c.Title) + "</a></li>\n")
+	}
+	s.WriteString("</ol>\n</nav>\n</body>\n</html>\n")
+	return s.String()
+}
+
+// chapterDocument renders a chapter as an XHTML content document
+func chapterDocument(b Book, c Chapter) string {
+	return xhtmlHeader(b.Language, c.Title) +
+		"<h1>" + esc(c.Title) + "</h1>\n" +
+		c.Body +
+		"\n</body>\n</html>\n"
+}
+
+// xhtmlHeader opens an XHTML document up to and including the body tag
+func xhtmlHeader(lang, title string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		"<!DOCTYPE html>\n" +
+		`<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="` + esc(lang) + `" lang="` + esc(lang) + `">` + "\n" +
+		"<head>\n<meta charset=\"utf-8\"/>\n<title>" + esc(title) + "</title>\n" +
+		`<link rel="stylesheet" type="text/css" href="style.css"/>` + "\n</head>\n<body>\n"
+}
+
+// esc escapes text for XML
+func esc(s string) string {
+	return html.EscapeString(s)
+}
+
+const stylesheet = `body { font-family: Georgia, serif; line-height: 1.5; }
+h1, h3, h4 { font-family: Helvetica, Arial, sans-serif; }
+blockquote { border-left: 3px solid #000; margin-left: 0; padding-left: 1em; font-style: italic; }
+aside { text-align: center; font-style: italic; }
+pre { white-space: pre-wrap; font-family: monospace; }
+figure { margin: 1em 0; text-align: center; }
+img { max-width: 100%; }
+figcaption { font-size: 0.85em; color: #79828b; }
+`
@@ -0,0 +1,86 @@
+package feed
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"telegraphcli/pkg/token"
+)
+
+// DatesFile is the name of the file recording when pages were first seen
+const DatesFile = "feed.json"
+
+// Dates records when each page first appeared in the page list. Telegraph
+// does not report publication dates, so these give feed items stable dates.
+type Dates struct {
+	FirstSeen map[string]time.Time `json:"first_seen"`
+}
+
+// GetDatesPath returns the path to the first-seen dates file
+func GetDatesPath() (string, error) {
+	tokenPath, err := token.GetTokenPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(tokenPath), DatesFile), nil
+}
+
+// LoadDates reads the first-seen dates, returning empty dates if none exist
+// yet
+func LoadDates() (*Dates, error) {
+	datesPath, err := GetDatesPath()
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Dates{FirstSeen: map[string]time.Time{}}
+	data, err := os.ReadFile(datesPath)
+	if os.IsNotExist(err) {
+		return d, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed dates: %v", err)
+	}
+
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, fmt.Errorf("failed to parse feed dates: %v", err)
+	}
+	if d.FirstSeen == nil {
+		d.FirstSeen = map[string]time.Time{}
+	}
+
+	return d, nil
+}
+
+// Save writes the first-seen dates
+func (d *Dates) Save() error {
+	datesPath, err := GetDatesPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode feed dates: %v", err)
+	}
+
+	if err := os.WriteFile(datesPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write feed dates: %v", err)
+	}
+
+	return nil
+}
+
+// Seen returns the date a page was first seen, recording t if the page is
+// new
+func (d *Dates) Seen(path string, t time.Time) time.Time {
+	if first, ok := d.FirstSeen[path]; ok {
+		return first
+	}
+	d.FirstSeen[path] = t.UTC().Truncate(time.Second)
+	return d.FirstSeen[path]
+}
@@ -0,0 +1,327 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// Feed holds the fields shared by all feed formats
+type Feed struct {
+	Title       string
+	Description string
+	// Link is the home page the feed belongs to
+	Link string
+	// FeedURL is where the feed itself is published, if known
+	FeedURL   string
+	Author    string
+	AuthorURL string
+	Items     []Item
+}
+
+// Item is a single feed entry
+type Item struct {
+	Title       string
+	URL         string
+	Description string
+	Author      string
+	Image       string
+	// Content is the rendered HTML of the page; it is left out when empty
+	Content   string
+	Published time.Time
+}
+
+// Formats lists the supported feed formats
+var Formats = []string{"atom", "rss", "jsonfeed"}
+
+// Encode renders the feed in the given format
+func Encode(format string, f Feed) ([]byte, error) {
+	switch format {
+	case "atom":
+		return Atom(f)
+	case "rss":
+		return RSS(f)
+	case "jsonfeed":
+		return JSONFeed(f)
+	}
+	return nil, fmt.Errorf("unknown feed format %q, use one of: %s", format, strings.Join(Formats, ", "))
+}
+
+// updated returns the date of the newest item, or the current time for an
+// empty feed
+func (f Feed) updated() time.Time {
+	var t time.Time
+	for _, item := range f.Items {
+		if item.Published.After(t) {
+			t = item.Published
+		}
+	}
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return t.UTC()
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  *atomAuthor `xml:"author,omitempty"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr,omitempty"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published"`
+	Links     []atomLink  `xml:"link"`
+	Author    *atomAuthor `xml:"author,omitempty"`
+	Summary   *atomText   `xml:"summary,omitempty"`
+	Content   *atomText   `xml:"content,omitempty"`
+}
+
+// Atom renders the feed as Atom 1.0
+func Atom(f Feed) ([]byte, error) {
+	a := atomFeed{
+		ID:      f.Link,
+		Title:   f.Title,
+		Updated: f.updated().Format(time.RFC3339),
+		Links:   []atomLink{{Href: f.Link}},
+	}
+	if f.FeedURL != "" {
+		a.ID = f.FeedURL
+		a.Links = append(a.Links, atomLink{Href: f.FeedURL, Rel: "self", Type: "application/atom+xml"})
+	}
+	// Atom requires an author for every entry, given either here or on the
+	// entry itself
+	a.Author = &atomAuthor{Name: f.Author, URI: f.AuthorURL}
+	if a.Author.Name == "" {
+		a.Author.Name = f.Title
+	}
+
+	for _, item := range f.Items {
+		date := item.Published.UTC().Format(time.RFC3339)
+		e := atomEntry{
+			ID:        item.URL,
+			Title:     item.Title,
+			Updated:   date,
+			Published: date,
+			Links:     []atomLink{{Href: item.URL}},
+		}
+		if item.Author != "" && item.Author != f.Author {
+			e.Author = &atomAuthor{Name: item.Author}
+		}
+		if item.Image != "" {
+			e.Links = append(e.Links, atomLink{Href: item.Image, Rel: "enclosure", Type: imageType(item.Image)})
+		}
+		if item.Description != "" {
+			e.Summary = &atomText{Body: item.Description}
+		}
+		if item.Content != "" {
+			e.Content = &atomText{Type: "html", Body: item.Content}
+		}
+		a.Entries = append(a.Entries, e)
+	}
+
+	return marshalXML(a)
+}
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Content string     `xml:"xmlns:content,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Self          *atomLink `xml:"atom:link,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Creator     string        `xml:"dc:creator,omitempty"`
+	Description string        `xml:"description,omitempty"`
+	Content     *rssCDATA     `xml:"content:encoded,omitempty"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssCDATA struct {
+	Body string `xml:",cdata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int    `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// RSS renders the feed as RSS 2.0
+func RSS(f Feed) ([]byte, error) {
+	description := f.Description
+	if description == "" {
+		description = f.Title
+	}
+
+	r := rssDocument{
+		Version: "2.0",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Content: "http://purl.org/rss/1.0/modules/content/",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   description,
+			LastBuildDate: f.updated().Format(time.RFC1123Z),
+		},
+	}
+	if f.FeedURL != "" {
+		r.Channel.Self = &atomLink{Href: f.FeedURL, Rel: "self", Type: "application/rss+xml"}
+	}
+
+	for _, item := range f.Items {
+		i := rssItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			GUID:        rssGUID{IsPermaLink: true, Value: item.URL},
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+			Creator:     item.Author,
+			Description: item.Description,
+		}
+		if item.Content != "" {
+			i.Content = &rssCDATA{Body: item.Content}
+		}
+		if item.Image != "" {
+			i.Enclosure = &rssEnclosure{URL: item.Image, Type: imageType(item.Image)}
+		}
+		r.Channel.Items = append(r.Channel.Items, i)
+	}
+
+	return marshalXML(r)
+}
+
+type jsonFeed struct {
+	Version     string       `json:"version"`
+	Title       string       `json:"title"`
+	HomePageURL string       `json:"home_page_url,omitempty"`
+	FeedURL     string       `json:"feed_url,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Authors     []jsonAuthor `json:"authors,omitempty"`
+	Items       []jsonItem   `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type jsonItem struct {
+	ID            string       `json:"id"`
+	URL           string       `json:"url"`
+	Title         string       `json:"title"`
+	Summary       string       `json:"summary,omitempty"`
+	ContentHTML   string       `json:"content_html,omitempty"`
+	ContentText   string       `json:"content_text,omitempty"`
+	Image         string       `json:"image,omitempty"`
+	DatePublished string       `json:"date_published"`
+	Authors       []jsonAuthor `json:"authors,omitempty"`
+}
+
+// JSONFeed renders the feed as JSON Feed 1.1
+func JSONFeed(f Feed) ([]byte, error) {
+	j := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		FeedURL:     f.FeedURL,
+		Description: f.Description,
+		Items:       []jsonItem{},
+	}
+	if f.Author != "" {
+		j.Authors = []jsonAuthor{{Name: f.Author, URL: f.AuthorURL}}
+	}
+
+	for _, item := range f.Items {
+		i := jsonItem{
+			ID:            item.URL,
+			URL:           item.URL,
+			Title:         item.Title,
+			Summary:       item.Description,
+			ContentHTML:   item.Content,
+			Image:         item.Image,
+			DatePublished: item.Published.UTC().Format(time.RFC3339),
+		}
+		// Every item needs content_html or content_text
+		if i.ContentHTML == "" {
+			i.ContentText = item.Description
+		}
+		if item.Author != "" && item.Author != f.Author {
+			i.Authors = []jsonAuthor{{Name: item.Author}}
+		}
+		j.Items = append(j.Items, i)
+	}
+
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode feed: %v", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// marshalXML encodes v as an indented XML document
+func marshalXML(v interface{}) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode feed: %v", err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// imageType guesses the media type of an image from its extension
+func imageType(src string) string {
+	switch strings.ToLower(path.Ext(src)) {
+	case ".png":
+		return "image/png"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	}
+	return "image/jpeg"
+}
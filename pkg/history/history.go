@@ -0,0 +1,202 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+
+	"telegraphcli/pkg/token"
+)
+
+// HistoryDir is the name of the directory holding page snapshots
+const HistoryDir = "history"
+
+// Snapshot is the remote state of a page before it was changed
+type Snapshot struct {
+	Rev        int
+	Time       time.Time
+	Reason     string
+	Title      string
+	AuthorName string
+	AuthorURL  string
+	Content    []telegraph.Node
+}
+
+// snapshotFile is the stored form of a snapshot
+type snapshotFile struct {
+	Rev        int             `json:"rev"`
+	Time       time.Time       `json:"time"`
+	Reason     string          `json:"reason"`
+	Title      string          `json:"title"`
+	AuthorName string          `json:"author_name,omitempty"`
+	AuthorURL  string          `json:"author_url,omitempty"`
+	Content    json.RawMessage `json:"content"`
+}
+
+// GetHistoryPath returns the directory holding the snapshots of a page
+func GetHistoryPath(path string) (string, error) {
+	tokenPath, err := token.GetTokenPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(tokenPath), HistoryDir, url.PathEscape(path)), nil
+}
+
+// Save stores a snapshot of a page as its next revision
+func Save(path string, s Snapshot) (Snapshot, error) {
+	dir, err := GetHistoryPath(path)
+	if err != nil {
+		return s, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return s, fmt.Errorf("failed to create history directory: %v", err)
+	}
+
+	revs, err := revisions(dir)
+	if err != nil {
+		return s, err
+	}
+	s.Rev = 1
+	if len(revs) > 0 {
+		s.Rev = revs[len(revs)-1] + 1
+	}
+	if s.Time.IsZero() {
+		s.Time = time.Now()
+	}
+
+	content, err := EncodeNodes(s.Content)
+	if err != nil {
+		return s, fmt.Errorf("failed to encode snapshot: %v", err)
+	}
+	data, err := json.MarshalIndent(snapshotFile{
+		Rev:        s.Rev,
+		Time:       s.Time.UTC(),
+		Reason:     s.Reason,
+		Title:      s.Title,
+		AuthorName: s.AuthorName,
+		AuthorURL:  s.AuthorURL,
+		Content:    content,
+	}, "", "  ")
+	if err != nil {
+		return s, fmt.Errorf("failed to encode snapshot: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, revisionFile(s.Rev)), data, 0600); err != nil {
+		return s, fmt.Errorf("failed to write snapshot: %v", err)
+	}
+	return s, nil
+}
+
+// List returns the snapshots of a page, oldest first
+func List(path string) ([]Snapshot, error) {
+	dir, err := GetHistoryPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	revs, err := revisions(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]Snapshot, 0, len(revs))
+	for _, rev := range revs {
+		s, err := load(dir, rev)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, nil
+}
+
+// Load returns one snapshot of a page
+func Load(path string, rev int) (Snapshot, error) {
+	dir, err := GetHistoryPath(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return load(dir, rev)
+}
+
+// Latest returns the newest snapshot of a page
+func Latest(path string) (Snapshot, error) {
+	dir, err := GetHistoryPath(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	revs, err := revisions(dir)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if len(revs) == 0 {
+		return Snapshot{}, fmt.Errorf("no history for %s", path)
+	}
+	return load(dir, revs[len(revs)-1])
+}
+
+// load reads a snapshot file
+func load(dir string, rev int) (Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(dir, revisionFile(rev)))
+	if os.IsNotExist(err) {
+		return Snapshot{}, fmt.Errorf("revision %d does not exist", rev)
+	}
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to read snapshot: %v", err)
+	}
+
+	var f snapshotFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to parse revision %d: %v", rev, err)
+	}
+	content, err := DecodeNodes(f.Content)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to parse revision %d: %v", rev, err)
+	}
+
+	return Snapshot{
+		Rev:        f.Rev,
+		Time:       f.Time,
+		Reason:     f.Reason,
+		Title:      f.Title,
+		AuthorName: f.AuthorName,
+		AuthorURL:  f.AuthorURL,
+		Content:    content,
+	}, nil
+}
+
+// revisions returns the revision numbers stored in dir in ascending order
+func revisions(dir string) ([]int, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %v", err)
+	}
+
+	var revs []int
+	for _, e := range entries {
+		rev, err := strconv.Atoi(strings.TrimSuffix(e.Name(), ".json"))
+		if err == nil && strings.HasSuffix(e.Name(), ".json") {
+			revs = append(revs, rev)
+		}
+	}
+	sort.Ints(revs)
+	return revs, nil
+}
+
+// revisionFile returns the file name of a revision
+func revisionFile(rev int) string {
+	return fmt.Sprintf("%06d.json", rev)
+}
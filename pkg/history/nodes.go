@@ -0,0 +1,112 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+)
+
+// jsonNode is a node in the Telegraph API format: a JSON string for text or
+// an object with tag, attrs and children for an element
+type jsonNode struct {
+	Text     string
+	Tag      string
+	Attrs    map[string]string
+	Children []jsonNode
+}
+
+type jsonElement struct {
+	Tag      string            `json:"tag"`
+	Attrs    map[string]string `json:"attrs,omitempty"`
+	Children []jsonNode        `json:"children,omitempty"`
+}
+
+func (n jsonNode) MarshalJSON() ([]byte, error) {
+	if n.Tag == "" {
+		return json.Marshal(n.Text)
+	}
+	return json.Marshal(jsonElement{Tag: n.Tag, Attrs: n.Attrs, Children: n.Children})
+}
+
+func (n *jsonNode) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		*n = jsonNode{}
+		return json.Unmarshal(data, &n.Text)
+	}
+	var el jsonElement
+	if err := json.Unmarshal(data, &el); err != nil {
+		return err
+	}
+	*n = jsonNode{Tag: el.Tag, Attrs: el.Attrs, Children: el.Children}
+	return nil
+}
+
+// EncodeNodes converts telegraph nodes to the Telegraph API JSON format
+func EncodeNodes(nodes []telegraph.Node) ([]byte, error) {
+	return json.Marshal(toJSON(nodes))
+}
+
+// DecodeNodes parses telegraph nodes from the Telegraph API JSON format
+func DecodeNodes(data []byte) ([]telegraph.Node, error) {
+	var nodes []jsonNode
+	if err := json.Unmarshal(data, &nodes); err != nil {
+		return nil, err
+	}
+	return fromJSON(nodes)
+}
+
+func toJSON(nodes []telegraph.Node) []jsonNode {
+	out := make([]jsonNode, 0, len(nodes))
+	for _, n := range nodes {
+		if n.Element == nil {
+			out = append(out, jsonNode{Text: n.Text})
+			continue
+		}
+		var attrs map[string]string
+		for _, key := range []string{"href", "src"} {
+			if value, ok := n.Element.Attrs[key]; ok {
+				if attrs == nil {
+					attrs = map[string]string{}
+				}
+				attrs[key] = value
+			}
+		}
+		out = append(out, jsonNode{
+			Tag:      n.Element.Tag.Atom().String(),
+			Attrs:    attrs,
+			Children: toJSON(n.Element.Children),
+		})
+	}
+	return out
+}
+
+func fromJSON(nodes []jsonNode) ([]telegraph.Node, error) {
+	out := make([]telegraph.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if n.Tag == "" {
+			out = append(out, telegraph.Node{Text: n.Text})
+			continue
+		}
+
+		a := atom.Lookup([]byte(n.Tag))
+		if a == 0 {
+			return nil, fmt.Errorf("unknown tag %q", n.Tag)
+		}
+		tag, err := telegraph.NewTag(a)
+		if err != nil {
+			return nil, fmt.Errorf("tag %q: %v", n.Tag, err)
+		}
+		children, err := fromJSON(n.Children)
+		if err != nil {
+			return nil, err
+		}
+
+		el := telegraph.NewNodeElement(tag)
+		el.Attrs = n.Attrs
+		el.Children = children
+		out = append(out, telegraph.Node{Element: el})
+	}
+	return out, nil
+}
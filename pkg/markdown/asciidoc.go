@@ -0,0 +1,246 @@
+package markdown
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	adocHeading   = regexp.MustCompile(`^(={1,6})\s+(.+?)\s*=*$`)
+	adocListItem  = regexp.MustCompile(`^\s*(\*+|-|\.+|\d+\.)\s+(.*)$`)
+	adocAttribute = regexp.MustCompile(`^:[\w-]+!?:`)
+	adocBlockAttr = regexp.MustCompile(`^\[.*\]$`)
+	adocImage     = regexp.MustCompile(`^image::([^\[]+)\[([^\]]*)\]$`)
+	adocLink      = regexp.MustCompile(`^(?:link:|(https?://|mailto:))([^\s\[]+)\[([^\]]*)\]`)
+	adocInlineImg = regexp.MustCompile(`^image:([^\s\[:][^\s\[]*)\[([^\]]*)\]`)
+	adocXref      = regexp.MustCompile(`^<<([^,>]+)(?:,\s*([^>]+))?>>`)
+)
+
+// ParseAsciiDoc converts a practical subset of AsciiDoc to telegraph nodes:
+// section titles, paragraphs, lists, listing and literal blocks, quote and
+// sidebar blocks, block and inline images, links and basic inline formatting.
+// Document attributes, block attributes, block titles and comments are
+// dropped.
+func ParseAsciiDoc(r io.Reader, opts Options) ([]telegraph.Node, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &asciidocParser{opts: opts}
+	p.syntax = inlineSyntax{
+		spans: []span{
+			{open: "**", close: "**", tag: atom.Strong},
+			{open: "__", close: "__", tag: atom.Em},
+			{open: "``", close: "``", tag: atom.Code, literal: true},
+			{open: "*", close: "*", tag: atom.Strong},
+			{open: "_", close: "_", tag: atom.Em},
+			{open: "`", close: "`", tag: atom.Code, literal: true},
+			{open: "+", close: "+", literal: true},
+			{open: "#", close: "#"},
+		},
+		link: p.link,
+	}
+
+	return p.blocks(lines), nil
+}
+
+// asciidocParser converts AsciiDoc lines to telegraph nodes
+type asciidocParser struct {
+	opts   Options
+	syntax inlineSyntax
+}
+
+// blocks converts a sequence of lines to block nodes
+func (p *asciidocParser) blocks(lines []string) []telegraph.Node {
+	b := &blockBuilder{inline: p.syntax.parse}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			b.flush()
+
+		case trimmed == "////":
+			b.flush()
+			_, i = delimitedBlock(lines, i)
+
+		case strings.HasPrefix(trimmed, "//"):
+			// Line comment
+
+		case len(b.paragraph) == 0 && (adocAttribute.MatchString(trimmed) || adocBlockAttr.MatchString(trimmed)):
+			// Document or block attributes
+
+		case len(b.paragraph) == 0 && strings.HasPrefix(trimmed, ".") && len(trimmed) > 1 &&
+			trimmed[1] != '.' && trimmed[1] != ' ' && i+1 < len(lines) && adocStartsBlock(lines[i+1]):
+			// Block title
+
+		case adocHeading.MatchString(line):
+			m := adocHeading.FindStringSubmatch(line)
+			// "=" is the document title and "==" a level 1 section
+			b.add(headingNode(len(m[1])-1, p.syntax.parse(m[2])))
+
+		case isDelimiter(trimmed, '-') || isDelimiter(trimmed, '.'):
+			body, end := delimitedBlock(lines, i)
+			b.add(codeNode(strings.Join(body, "\n")))
+			i = end
+
+		case isDelimiter(trimmed, '_'):
+			body, end := delimitedBlock(lines, i)
+			b.add(Element(atom.Blockquote, p.blocks(body)...))
+			i = end
+
+		case isDelimiter(trimmed, '*'):
+			body, end := delimitedBlock(lines, i)
+			b.add(Element(atom.Aside, p.blocks(body)...))
+			i = end
+
+		case isDelimiter(trimmed, '='):
+			// Example blocks have no Telegraph equivalent; keep their content
+			body, end := delimitedBlock(lines, i)
+			b.add(p.blocks(body)...)
+			i = end
+
+		case trimmed == "'''" || trimmed == "---" || trimmed == "***":
+			b.add(Element(atom.Hr))
+
+		case adocImage.MatchString(trimmed):
+			m := adocImage.FindStringSubmatch(trimmed)
+			b.add(figureNode(resolvePath(p.opts.BaseDir, m[1]), imageAlt(m[2])))
+
+		case len(b.paragraph) == 0 && adocListItem.MatchString(line):
+			list, end := p.list(lines, i)
+			b.add(list)
+			i = end - 1
+
+		case len(b.paragraph) == 0 && indentation(line) > 0:
+			// Literal paragraph
+			body, end := indentedBlock(lines, i, 0)
+			b.add(codeNode(strings.Join(body, "\n")))
+			i = end - 1
+
+		default:
+			b.paragraph = append(b.paragraph, line)
+		}
+	}
+
+	return b.result()
+}
+
+// adocStartsBlock reports whether a line starts a block that can have a
+// title: an image, a delimited block, a list or the attributes of a block
+func adocStartsBlock(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, c := range []byte{'-', '.', '_', '*', '='} {
+		if isDelimiter(trimmed, c) {
+			return true
+		}
+	}
+	return adocImage.MatchString(trimmed) || adocListItem.MatchString(line) ||
+		adocBlockAttr.MatchString(trimmed) || strings.HasPrefix(trimmed, "|===")
+}
+
+// list reads a list starting at lines[start]. Nested items are flattened.
+func (p *asciidocParser) list(lines []string, start int) (telegraph.Node, int) {
+	ordered := isOrderedMarker(adocListItem.FindStringSubmatch(lines[start])[1])
+
+	var items [][]string
+	i := start
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if m := adocListItem.FindStringSubmatch(line); m != nil {
+			if isOrderedMarker(m[1]) != ordered {
+				break
+			}
+			items = append(items, []string{m[2]})
+			continue
+		}
+		// A "+" line attaches the next paragraph to the item
+		if trimmed == "" || trimmed == "+" {
+			if trimmed == "" && (i+1 >= len(lines) || !adocListItem.MatchString(lines[i+1])) {
+				break
+			}
+			continue
+		}
+		items[len(items)-1] = append(items[len(items)-1], line)
+	}
+
+	content := make([][]telegraph.Node, 0, len(items))
+	for _, item := range items {
+		content = append(content, p.syntax.parse(joinParagraph(item)))
+	}
+	return listNode(ordered, content), i
+}
+
+// link parses links, cross references and inline images
+func (p *asciidocParser) link(s string, i int) ([]telegraph.Node, int) {
+	if i > 0 && isWordByte(s, i-1) {
+		return nil, 0
+	}
+	rest := s[i:]
+
+	if m := adocInlineImg.FindStringSubmatch(rest); m != nil {
+		return []telegraph.Node{imageNode(resolvePath(p.opts.BaseDir, m[1]))}, len(m[0])
+	}
+
+	if m := adocLink.FindStringSubmatch(rest); m != nil {
+		href := m[1] + m[2]
+		text := m[3]
+		if text == "" {
+			text = href
+		}
+		href = resolvePath(p.opts.BaseDir, href)
+		return []telegraph.Node{Link(href, p.syntax.parse(text)...)}, len(m[0])
+	}
+
+	if m := adocXref.FindStringSubmatch(rest); m != nil {
+		text := m[2]
+		if text == "" {
+			text = m[1]
+		}
+		return []telegraph.Node{Link("#"+m[1], telegraph.Node{Text: text})}, len(m[0])
+	}
+
+	return nil, 0
+}
+
+// imageAlt returns the alt text from an image macro's attribute list
+func imageAlt(attrs string) string {
+	alt, _, _ := strings.Cut(attrs, ",")
+	return strings.Trim(strings.TrimSpace(alt), `"`)
+}
+
+// isDelimiter reports whether a line is a block delimiter made of at least
+// four c characters
+func isDelimiter(line string, c byte) bool {
+	return len(line) >= 4 && strings.Count(line, string(c)) == len(line)
+}
+
+// delimitedBlock returns the lines between the delimiter at lines[start] and
+// its matching closing delimiter, and the index of the closing line. An
+// unterminated block runs to the end of the input.
+func delimitedBlock(lines []string, start int) ([]string, int) {
+	delim := strings.TrimSpace(lines[start])
+	for i := start + 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == delim {
+			return lines[start+1 : i], i
+		}
+	}
+	return lines[start+1:], len(lines)
+}
+
+// isOrderedMarker reports whether a list marker starts an ordered list
+func isOrderedMarker(marker string) bool {
+	return strings.HasPrefix(marker, ".") || (marker != "" && marker[0] >= '0' && marker[0] <= '9')
+}
+
+func init() {
+	Register("asciidoc", ConverterFunc(ParseAsciiDoc), ".adoc", ".asciidoc", ".asc")
+}
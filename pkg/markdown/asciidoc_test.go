@@ -0,0 +1,86 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+)
+
+func TestParseAsciiDocBlockTitles(t *testing.T) {
+	p := func(children ...telegraph.Node) telegraph.Node { return Element(atom.P, children...) }
+
+	tests := []struct {
+		name     string
+		asciidoc string
+		want     []telegraph.Node
+	}{
+		{"title of a listing", ".Example\n----\ncode\n----", []telegraph.Node{Element(atom.Pre, Element(atom.Code, txt("code")))}},
+		{"title of a list", ".Steps\n* one", []telegraph.Node{Element(atom.Ul, Element(atom.Li, txt("one")))}},
+		{"title of attributes", ".Example\n[source,go]\n----\ncode\n----", []telegraph.Node{Element(atom.Pre, Element(atom.Code, txt("code")))}},
+		{"leading dot in text", ".NET is a framework.\nIt runs C#.", []telegraph.Node{p(txt(".NET is a framework. It runs C#."))}},
+		{"leading dot at the end", "Intro\n\n.gitignore", []telegraph.Node{p(txt("Intro")), p(txt(".gitignore"))}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAsciiDoc(strings.NewReader(tt.asciidoc), Options{})
+			if err != nil {
+				t.Fatalf("ParseAsciiDoc(%q) failed: %v", tt.asciidoc, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAsciiDoc(%q)\n got: %s\nwant: %s", tt.asciidoc, dump(got), dump(tt.want))
+			}
+		})
+	}
+}
+
+func TestParseAsciiDocMatchesMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		asciidoc string
+		markdown string
+	}{
+		{"headings", "== Section\n\n=== Subsection\n\n==== Subsubsection", "# Section\n## Subsection\n### Subsubsection"},
+		{"document title", "= Title\n\n== Section", "# Title\n# Section"},
+		{"paragraph", "Some\ntext.", "Some\ntext."},
+		{"bullet list", "* a\n* b", "- a\n- b"},
+		{"dash list", "- a\n- b", "- a\n- b"},
+		{"ordered list", ". a\n. b", "1. a\n2. b"},
+		{"list after paragraph", "Text.\n\n* a\n* b", "Text.\n\n- a\n- b"},
+		{"paragraph after list", "* a\n\nText.", "- a\n\nText."},
+		{"listing block", "[source,go]\n----\nfunc f() {\n\treturn\n}\n----", "```go\nfunc f() {\n\treturn\n}\n```"},
+		{"literal block", "....\n  x\n....", "```\n  x\n```"},
+		{"literal paragraph", "  x := 1\n    y", "```\nx := 1\n  y\n```"},
+		{"tab indented literal paragraph", "\tx\n    \ty", "```\nx\ny\n```"},
+		{"quote block", "____\nQuoted.\n____", "> Quoted."},
+		{"link", "See https://example.com[the site].", "See [the site](https://example.com)."},
+		{"link macro", "link:other.html[Other]", "[Other](other.html)"},
+		{"autolink", "Go to https://example.com.", "Go to <https://example.com>."},
+		{"image", "image::img.png[]", "![](img.png)"},
+		{"image with alt", "image::img.png[A cat]", "![A cat](img.png)"},
+		{"inline image", "A image:img.png[] b", "A ![](img.png) b"},
+		{"emphasis", "*bold* _italic_ `code`", "**bold** *italic* `code`"},
+		{"intraword markup", "a*b*c snake_case_name", "a\\*b\\*c snake_case_name"},
+		{"rule", "Text.\n\n'''\n\nMore.", "Text.\n\n***\n\nMore."},
+		{"comments and attributes", ":toc:\n// comment\nText.", "Text."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAsciiDoc(strings.NewReader(tt.asciidoc), Options{})
+			if err != nil {
+				t.Fatalf("ParseAsciiDoc(%q) failed: %v", tt.asciidoc, err)
+			}
+			want, err := ParseReader(strings.NewReader(tt.markdown), Options{})
+			if err != nil {
+				t.Fatalf("ParseReader(%q) failed: %v", tt.markdown, err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ParseAsciiDoc(%q)\n got: %s\nwant: %s", tt.asciidoc, dump(got), dump(want))
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package markdown
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+)
+
+// Converter converts a source document to telegraph nodes
+type Converter interface {
+	Convert(r io.Reader, opts Options) ([]telegraph.Node, error)
+}
+
+// ConverterFunc adapts a function to the Converter interface
+type ConverterFunc func(r io.Reader, opts Options) ([]telegraph.Node, error)
+
+// Convert calls f(r, opts)
+func (f ConverterFunc) Convert(r io.Reader, opts Options) ([]telegraph.Node, error) {
+	return f(r, opts)
+}
+
+// DefaultFormat is used for files whose extension is not registered
+const DefaultFormat = "markdown"
+
+var (
+	// converters maps format names to converters
+	converters = map[string]Converter{}
+	// extensions maps lower-case file extensions to format names
+	extensions = map[string]string{}
+)
+
+// Register makes a converter available under a format name and for the given
+// file extensions. Registering a name or extension twice replaces the earlier
+// registration.
+func Register(format string, c Converter, exts ...string) {
+	converters[format] = c
+	for _, ext := range exts {
+		extensions[strings.ToLower(ext)] = format
+	}
+}
+
+// Lookup returns the converter registered for a format name
+func Lookup(format string) (Converter, error) {
+	c, ok := converters[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q (supported: %s)", format, strings.Join(Formats(), ", "))
+	}
+	return c, nil
+}
+
+// FormatFromPath returns the format registered for the extension of a file,
+// or DefaultFormat when there is none
+func FormatFromPath(filePath string) string {
+	if format, ok := extensions[strings.ToLower(filepath.Ext(filePath))]; ok {
+		return format
+	}
+	return DefaultFormat
+}
+
+// Formats returns the registered format names in sorted order
+func Formats() []string {
+	formats := make([]string, 0, len(converters))
+	for format := range converters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+func init() {
+	Register("markdown", ConverterFunc(ParseReader), ".md", ".markdown")
+	Register("html", ConverterFunc(ParseHTML), ".html", ".htm")
+}
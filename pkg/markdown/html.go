@@ -0,0 +1,371 @@
+package markdown
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlTags maps HTML elements to the Telegraph tag they become. Elements
+// missing from the map are unwrapped, keeping their children.
+var htmlTags = map[atom.Atom]atom.Atom{
+	atom.A:          atom.A,
+	atom.Aside:      atom.Aside,
+	atom.B:          atom.B,
+	atom.Blockquote: atom.Blockquote,
+	atom.Br:         atom.Br,
+	atom.Code:       atom.Code,
+	atom.Em:         atom.Em,
+	atom.Figcaption: atom.Figcaption,
+	atom.Figure:     atom.Figure,
+	atom.H1:         atom.H3,
+	atom.H2:         atom.H4,
+	atom.H3:         atom.H4,
+	atom.H4:         atom.H4,
+	atom.H5:         atom.H4,
+	atom.H6:         atom.H4,
+	atom.Hr:         atom.Hr,
+	atom.I:          atom.I,
+	atom.Iframe:     atom.Iframe,
+	atom.Img:        atom.Img,
+	atom.Li:         atom.Li,
+	atom.Ol:         atom.Ol,
+	atom.P:          atom.P,
+	atom.Pre:        atom.Pre,
+	atom.S:          atom.S,
+	atom.Strong:     atom.Strong,
+	atom.U:          atom.U,
+	atom.Ul:         atom.Ul,
+	atom.Video:      atom.Video,
+
+	// Close equivalents of tags Telegraph does not know
+	atom.Del:    atom.S,
+	atom.Strike: atom.S,
+	atom.Ins:    atom.U,
+	atom.Kbd:    atom.Code,
+	atom.Samp:   atom.Code,
+	atom.Tt:     atom.Code,
+	atom.Cite:   atom.I,
+	atom.Q:      atom.I,
+	atom.Tr:     atom.P,
+}
+
+// htmlDropped lists elements that are removed together with their content
+var htmlDropped = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Form:     true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Textarea: true,
+	atom.Input:    true,
+}
+
+// htmlBlocks lists unwrapped elements that are blocks of their own. Their
+// content is kept apart from the content around them: in paragraphs where
+// blocks are allowed, and by line breaks inside other elements.
+var htmlBlocks = map[atom.Atom]bool{
+	atom.Address:  true,
+	atom.Article:  true,
+	atom.Body:     true,
+	atom.Caption:  true,
+	atom.Center:   true,
+	atom.Dd:       true,
+	atom.Details:  true,
+	atom.Dialog:   true,
+	atom.Div:      true,
+	atom.Dl:       true,
+	atom.Dt:       true,
+	atom.Fieldset: true,
+	atom.Footer:   true,
+	atom.Header:   true,
+	atom.Hgroup:   true,
+	atom.Html:     true,
+	atom.Legend:   true,
+	atom.Main:     true,
+	atom.Menu:     true,
+	atom.Nav:      true,
+	atom.Section:  true,
+	atom.Summary:  true,
+	atom.Table:    true,
+	atom.Tbody:    true,
+	atom.Tfoot:    true,
+	atom.Thead:    true,
+}
+
+// htmlAttrs lists the attribute Telegraph accepts for each tag
+var htmlAttrs = map[atom.Atom]string{
+	atom.A:      "href",
+	atom.Img:    "src",
+	atom.Iframe: "src",
+	atom.Video:  "src",
+}
+
+// ParseHTML parses HTML from r and returns the content as telegraph nodes.
+// The document is sanitized to the tags and attributes Telegraph accepts:
+// h1 becomes h3 and h2 to h6 become h4, unsupported containers such as div,
+// span and table cells are unwrapped keeping their text, table rows become
+// paragraphs, and scripts, styles and form controls are removed. Text outside
+// of blocks, including that of unwrapped blocks such as div, is wrapped in
+// paragraphs.
+func ParseHTML(r io.Reader, opts Options) ([]telegraph.Node, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse html: %v", err)
+	}
+
+	c := &htmlConverter{mdConverter: mdConverter{opts: opts}}
+	return paragraphs(c.children(doc)), nil
+}
+
+// htmlConverter converts an x/net/html tree to telegraph nodes
+type htmlConverter struct {
+	mdConverter
+	inPre int
+	// inline counts the enclosing elements that hold inline content, where
+	// unwrapped blocks are set apart by line breaks instead of paragraphs
+	inline int
+}
+
+// children converts the children of n
+func (c *htmlConverter) children(n *html.Node) []telegraph.Node {
+	nodes := []telegraph.Node{}
+	breakNext := false
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		converted := c.node(child)
+		if len(trimBlankText(converted)) == 0 {
+			nodes = append(nodes, converted...)
+			continue
+		}
+		block := c.inline > 0 && isHTMLBlock(child)
+		if (block || breakNext) && len(trimBlankText(nodes)) > 0 {
+			nodes = append(nodes, Element(atom.Br))
+		}
+		breakNext = block
+		nodes = append(nodes, converted...)
+	}
+	return mergeText(nodes)
+}
+
+// node converts a single HTML node
+func (c *htmlConverter) node(n *html.Node) []telegraph.Node {
+	switch n.Type {
+	case html.TextNode:
+		if c.inPre > 0 {
+			return []telegraph.Node{{Text: n.Data}}
+		}
+		if n.Parent != nil && n.Parent.DataAtom == atom.Tr && strings.TrimSpace(n.Data) == "" {
+			// Indentation between cells, which get their own separator
+			return nil
+		}
+		return []telegraph.Node{{Text: collapseSpace(n.Data)}}
+	case html.DocumentNode:
+		return c.children(n)
+	case html.ElementNode:
+	default:
+		return nil
+	}
+
+	if htmlDropped[n.DataAtom] {
+		return nil
+	}
+
+	tag, ok := htmlTags[n.DataAtom]
+	if isHTMLBlock(n) {
+		if c.inline > 0 {
+			return c.children(n)
+		}
+		return paragraphs(c.children(n))
+	}
+	if !ok {
+		nodes := c.children(n)
+		// Keep unwrapped table cells apart from each other
+		if isTableCell(n) && isTableCell(nextElement(n)) {
+			nodes = append(nodes, telegraph.Node{Text: " | "})
+		}
+		return nodes
+	}
+
+	if tag == atom.Pre {
+		c.inPre++
+		defer func() { c.inPre-- }()
+	}
+	if tag != atom.Blockquote && tag != atom.Aside {
+		c.inline++
+		defer func() { c.inline-- }()
+	}
+
+	children := c.children(n)
+	if isBlockTag(tag) {
+		children = trimBlankText(children)
+	}
+	if c.inline == 0 && hasBlockNode(children) {
+		// Quotes mixing text with unwrapped blocks
+		children = paragraphs(children)
+	}
+
+	el := Element(tag, children...)
+	if name, ok := htmlAttrs[tag]; ok {
+		value := htmlAttr(n, name)
+		if value == "" || isDangerousURL(value) {
+			// Links without a usable target are kept as plain text, media
+			// without one is dropped
+			if tag == atom.A {
+				return children
+			}
+			return nil
+		}
+		setAttr(el, name, c.resolve(value))
+	}
+
+	return []telegraph.Node{el}
+}
+
+// isHTMLBlock reports whether n is an unwrapped block element. List items
+// outside of lists are unwrapped too.
+func isHTMLBlock(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if n.DataAtom == atom.Li {
+		return n.Parent == nil || (n.Parent.DataAtom != atom.Ul && n.Parent.DataAtom != atom.Ol)
+	}
+	return htmlBlocks[n.DataAtom]
+}
+
+// isTableCell reports whether n is a td or th element
+func isTableCell(n *html.Node) bool {
+	return n != nil && n.Type == html.ElementNode && (n.DataAtom == atom.Td || n.DataAtom == atom.Th)
+}
+
+// nextElement returns the next sibling of n that is an element
+func nextElement(n *html.Node) *html.Node {
+	for next := n.NextSibling; next != nil; next = next.NextSibling {
+		if next.Type == html.ElementNode {
+			return next
+		}
+	}
+	return nil
+}
+
+// htmlAttr returns the value of an attribute of n
+func htmlAttr(n *html.Node, name string) string {
+	for _, attr := range n.Attr {
+		if attr.Namespace == "" && strings.EqualFold(attr.Key, name) {
+			return strings.TrimSpace(attr.Val)
+		}
+	}
+	return ""
+}
+
+// isDangerousURL reports whether a link uses a scheme that can run script
+func isDangerousURL(value string) bool {
+	lower := strings.ToLower(strings.TrimSpace(value))
+	return strings.HasPrefix(lower, "javascript:") || strings.HasPrefix(lower, "vbscript:") ||
+		(strings.HasPrefix(lower, "data:") && !strings.HasPrefix(lower, "data:image/"))
+}
+
+// isBlockTag reports whether a Telegraph tag is a block element
+func isBlockTag(tag atom.Atom) bool {
+	switch tag {
+	case atom.P, atom.H3, atom.H4, atom.Blockquote, atom.Aside, atom.Li, atom.Ul, atom.Ol,
+		atom.Figure, atom.Figcaption:
+		return true
+	}
+	return false
+}
+
+// collapseSpace collapses runs of whitespace into a single space, as HTML
+// rendering does outside of pre
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' {
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// trimBlankText removes whitespace at the edges of a block and next to block
+// elements and line breaks, dropping text nodes that end up empty
+func trimBlankText(nodes []telegraph.Node) []telegraph.Node {
+	trimmed := make([]telegraph.Node, 0, len(nodes))
+	for i, node := range nodes {
+		if node.Element == nil {
+			text := node.Text
+			if i == 0 || isBlockNode(nodes, i-1) || isBreakNode(nodes, i-1) {
+				text = strings.TrimLeft(text, " ")
+			}
+			if i == len(nodes)-1 || isBlockNode(nodes, i+1) || isBreakNode(nodes, i+1) {
+				text = strings.TrimRight(text, " ")
+			}
+			if text == "" {
+				continue
+			}
+			node.Text = text
+		}
+		trimmed = append(trimmed, node)
+	}
+	return trimmed
+}
+
+// paragraphs wraps the runs of inline nodes between blocks in paragraphs
+func paragraphs(nodes []telegraph.Node) []telegraph.Node {
+	nodes = trimBlankText(nodes)
+	blocks := []telegraph.Node{}
+	start := 0
+	for i := 0; i <= len(nodes); i++ {
+		if i < len(nodes) && !isBlockNode(nodes, i) {
+			continue
+		}
+		if i > start {
+			blocks = append(blocks, Element(atom.P, nodes[start:i]...))
+		}
+		if i < len(nodes) {
+			blocks = append(blocks, nodes[i])
+		}
+		start = i + 1
+	}
+	return blocks
+}
+
+// hasBlockNode reports whether any of nodes is a block element
+func hasBlockNode(nodes []telegraph.Node) bool {
+	for i := range nodes {
+		if isBlockNode(nodes, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBlockNode reports whether nodes[i] exists and is a block element
+func isBlockNode(nodes []telegraph.Node, i int) bool {
+	if i < 0 || i >= len(nodes) || nodes[i].Element == nil {
+		return false
+	}
+	tag := nodes[i].Element.Tag.Atom()
+	return isBlockTag(tag) || tag == atom.Pre || tag == atom.Hr
+}
+
+// isBreakNode reports whether nodes[i] exists and is a br element
+func isBreakNode(nodes []telegraph.Node, i int) bool {
+	return i >= 0 && i < len(nodes) && nodes[i].Element != nil && nodes[i].Element.Tag.Atom() == atom.Br
+}
@@ -0,0 +1,46 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+)
+
+func TestParseHTMLBlocks(t *testing.T) {
+	p := func(children ...telegraph.Node) telegraph.Node { return Element(atom.P, children...) }
+	li := func(children ...telegraph.Node) telegraph.Node { return Element(atom.Li, children...) }
+	br := Element(atom.Br)
+
+	tests := []struct {
+		name string
+		html string
+		want []telegraph.Node
+	}{
+		{"divs break paragraphs", "<div>a</div><div>b</div><span>c</span>", []telegraph.Node{p(txt("a")), p(txt("b")), p(txt("c"))}},
+		{"loose inline content", "Hello <b>world</b>", []telegraph.Node{p(txt("Hello "), Element(atom.B, txt("world")))}},
+		{"text between blocks", "<p>x</p>loose <i>y</i><h1>T</h1>", []telegraph.Node{p(txt("x")), p(txt("loose "), Element(atom.I, txt("y"))), Element(atom.H3, txt("T"))}},
+		{"section", "<section><h2>S</h2>text<p>para</p></section>", []telegraph.Node{Element(atom.H4, txt("S")), p(txt("text")), p(txt("para"))}},
+		{"list items outside lists", "<li>one</li><li>two</li>", []telegraph.Node{p(txt("one")), p(txt("two"))}},
+		{"divs in list items", "<ul><li><div>a</div> <div>b</div></li><li>c<ul><li>d</li></ul></li></ul>", []telegraph.Node{Element(atom.Ul,
+			li(txt("a"), br, txt("b")),
+			li(txt("c"), Element(atom.Ul, li(txt("d")))))}},
+		{"quote", "<blockquote>quote</blockquote>", []telegraph.Node{Element(atom.Blockquote, txt("quote"))}},
+		{"quote with divs", "<blockquote>t<div>x</div></blockquote>", []telegraph.Node{Element(atom.Blockquote, p(txt("t")), p(txt("x")))}},
+		{"table rows", "<table>\n<tr>\n  <th>a</th>\n  <th>b</th>\n</tr>\n<tr><td>c</td> <td>d</td></tr>\n</table>", []telegraph.Node{p(txt("a | b")), p(txt("c | d"))}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseHTML(strings.NewReader(tt.html), Options{})
+			if err != nil {
+				t.Fatalf("ParseHTML(%q) failed: %v", tt.html, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseHTML(%q)\n got: %s\nwant: %s", tt.html, dump(got), dump(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package markdown
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+)
+
+// This file holds the pieces shared by the AsciiDoc, Org-mode and
+// reStructuredText converters. They only cover a practical subset of each
+// language: sections, paragraphs, lists, code blocks, links, images and
+// quotes. Nested lists are flattened into their parent list.
+
+// span describes an inline markup span such as *bold*
+type span struct {
+	open, close string
+	// tag is the element the span becomes; 0 keeps the content as plain text
+	tag atom.Atom
+	// literal spans are not parsed for further markup
+	literal bool
+}
+
+// inlineSyntax describes the inline markup of a lightweight markup language
+type inlineSyntax struct {
+	spans []span
+	// link parses a link or other construct starting at s[i], returning the
+	// resulting nodes and the number of bytes consumed, or 0 if none matches
+	link func(s string, i int) ([]telegraph.Node, int)
+}
+
+// parse converts a run of inline text to telegraph nodes
+func (syn *inlineSyntax) parse(s string) []telegraph.Node {
+	nodes := []telegraph.Node{}
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, telegraph.Node{Text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		if syn.link != nil {
+			if link, n := syn.link(s, i); n > 0 {
+				flush()
+				nodes = append(nodes, link...)
+				i += n
+				continue
+			}
+		}
+		if link, n := autolink(s, i); n > 0 {
+			flush()
+			nodes = append(nodes, link)
+			i += n
+			continue
+		}
+		if content, n := syn.span(s, i); n > 0 {
+			flush()
+			nodes = append(nodes, content...)
+			i += n
+			continue
+		}
+		text.WriteByte(s[i])
+		i++
+	}
+	flush()
+
+	return mergeText(nodes)
+}
+
+// span matches an inline span starting at s[i]
+func (syn *inlineSyntax) span(s string, i int) ([]telegraph.Node, int) {
+	if i > 0 && isWordByte(s, i-1) {
+		return nil, 0
+	}
+
+	for _, sp := range syn.spans {
+		if !strings.HasPrefix(s[i:], sp.open) {
+			continue
+		}
+		start := i + len(sp.open)
+		if start >= len(s) || s[start] == ' ' {
+			continue
+		}
+
+		end := findClose(s, start, sp.close)
+		if end < 0 {
+			continue
+		}
+
+		content := s[start:end]
+		var children []telegraph.Node
+		if sp.literal {
+			children = []telegraph.Node{{Text: content}}
+		} else {
+			children = syn.parse(content)
+		}
+		n := end + len(sp.close) - i
+		if sp.tag == 0 {
+			return children, n
+		}
+		return []telegraph.Node{Element(sp.tag, children...)}, n
+	}
+
+	return nil, 0
+}
+
+// findClose finds a closing delimiter that is preceded by a non-space and not
+// followed by a word character
+func findClose(s string, start int, close string) int {
+	for j := start + 1; j <= len(s)-len(close); j++ {
+		if !strings.HasPrefix(s[j:], close) || s[j-1] == ' ' {
+			continue
+		}
+		after := j + len(close)
+		if after < len(s) && isWordByte(s, after) {
+			continue
+		}
+		return j
+	}
+	return -1
+}
+
+// isWordByte reports whether the rune at s[i] is a letter or digit
+func isWordByte(s string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	if r == utf8.RuneError && i > 0 {
+		r, _ = utf8.DecodeLastRuneInString(s[:i+1])
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// autolink turns a bare http or https URL at s[i] into a link
+func autolink(s string, i int) (telegraph.Node, int) {
+	if i > 0 && !strings.ContainsRune(" \t(<[", rune(s[i-1])) {
+		return telegraph.Node{}, 0
+	}
+	if !strings.HasPrefix(s[i:], "http://") && !strings.HasPrefix(s[i:], "https://") {
+		return telegraph.Node{}, 0
+	}
+
+	end := i
+	for end < len(s) && !strings.ContainsRune(" \t<>\"[]", rune(s[end])) {
+		end++
+	}
+	for end > i && strings.ContainsRune(".,;:!?)'", rune(s[end-1])) {
+		end--
+	}
+
+	href := s[i:end]
+	return Link(href, telegraph.Node{Text: href}), end - i
+}
+
+// headingNode returns the Telegraph heading for a section level, where 1 is
+// the top level
+func headingNode(level int, children []telegraph.Node) telegraph.Node {
+	if level <= 1 {
+		return Element(atom.H3, children...)
+	}
+	return Element(atom.H4, children...)
+}
+
+// listNode builds a ul or ol element from list items
+func listNode(ordered bool, items [][]telegraph.Node) telegraph.Node {
+	tag := atom.Ul
+	if ordered {
+		tag = atom.Ol
+	}
+	lis := make([]telegraph.Node, 0, len(items))
+	for _, item := range items {
+		lis = append(lis, Element(atom.Li, item...))
+	}
+	return Element(tag, lis...)
+}
+
+// codeNode builds a pre > code element
+func codeNode(code string) telegraph.Node {
+	return Element(atom.Pre, Element(atom.Code, telegraph.Node{Text: code}))
+}
+
+// imageNode builds an img element
+func imageNode(src string) telegraph.Node {
+	img := Element(atom.Img)
+	setAttr(img, "src", src)
+	return img
+}
+
+// figureNode builds a figure with an optional caption
+func figureNode(src, caption string) telegraph.Node {
+	children := []telegraph.Node{imageNode(src)}
+	if caption != "" {
+		children = append(children, Element(atom.Figcaption, telegraph.Node{Text: caption}))
+	}
+	return Element(atom.Figure, children...)
+}
+
+// isImagePath reports whether a link target looks like an image
+func isImagePath(target string) bool {
+	target = strings.ToLower(target)
+	if i := strings.IndexAny(target, "?#"); i >= 0 {
+		target = target[:i]
+	}
+	for _, ext := range []string{".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp"} {
+		if strings.HasSuffix(target, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// readLines reads r into lines without line terminators
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read input: %v", err)
+	}
+	return lines, nil
+}
+
+// indentation returns the number of leading spaces of a line, counting tabs
+// as eight
+func indentation(line string) int {
+	n := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			n++
+		case '\t':
+			n += 8 - n%8
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// indentedBlock collects the lines from start on that are blank or indented
+// more than minIndent. It returns the block with the common indentation and
+// trailing blank lines removed, and the index of the first line after it.
+func indentedBlock(lines []string, start, minIndent int) ([]string, int) {
+	end := start
+	for end < len(lines) {
+		line := lines[end]
+		if strings.TrimSpace(line) != "" && indentation(line) <= minIndent {
+			break
+		}
+		end++
+	}
+
+	block := lines[start:end]
+	for len(block) > 0 && strings.TrimSpace(block[len(block)-1]) == "" {
+		block = block[:len(block)-1]
+	}
+	return dedent(block), end
+}
+
+// dedent removes the indentation common to all non-blank lines
+func dedent(lines []string) []string {
+	common := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if n := indentation(line); common < 0 || n < common {
+			common = n
+		}
+	}
+
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = trimIndent(line, common)
+	}
+	return out
+}
+
+// trimIndent removes width columns of leading whitespace from a line,
+// counting tabs the way indentation does. A tab that reaches past width
+// leaves the rest of its columns as spaces.
+func trimIndent(line string, width int) string {
+	col := 0
+	for i := 0; i < len(line); i++ {
+		if col >= width {
+			return strings.Repeat(" ", col-width) + line[i:]
+		}
+		switch line[i] {
+		case ' ':
+			col++
+		case '\t':
+			col += 8 - col%8
+		default:
+			return line[i:]
+		}
+	}
+	return ""
+}
+
+// joinParagraph joins paragraph lines with single spaces
+func joinParagraph(lines []string) string {
+	parts := make([]string, 0, len(lines))
+	for _, line := range lines {
+		parts = append(parts, strings.TrimSpace(line))
+	}
+	return strings.Join(parts, " ")
+}
+
+// blockBuilder accumulates block nodes and the paragraph being read
+type blockBuilder struct {
+	nodes     []telegraph.Node
+	paragraph []string
+	inline    func(string) []telegraph.Node
+}
+
+// add appends block nodes after flushing the current paragraph
+func (b *blockBuilder) add(nodes ...telegraph.Node) {
+	b.flush()
+	b.nodes = append(b.nodes, nodes...)
+}
+
+// flush turns the collected paragraph lines into a p element
+func (b *blockBuilder) flush() {
+	if len(b.paragraph) == 0 {
+		return
+	}
+	b.nodes = append(b.nodes, Element(atom.P, b.inline(joinParagraph(b.paragraph))...))
+	b.paragraph = nil
+}
+
+// result flushes and returns the collected nodes
+func (b *blockBuilder) result() []telegraph.Node {
+	b.flush()
+	if b.nodes == nil {
+		return []telegraph.Node{}
+	}
+	return b.nodes
+}
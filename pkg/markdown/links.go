@@ -0,0 +1,139 @@
+package markdown
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// UnresolvedLinksError is returned when Options.Links is set and the document
+// links to local Markdown files that have not been published
+type UnresolvedLinksError struct {
+	Links []string
+}
+
+func (e *UnresolvedLinksError) Error() string {
+	return fmt.Sprintf("links to unpublished files: %s", strings.Join(e.Links, ", "))
+}
+
+// isMarkdownFile reports whether a resolved link destination points at a
+// local Markdown file
+func isMarkdownFile(dest string) bool {
+	if strings.Contains(dest, "://") || strings.HasPrefix(dest, "mailto:") {
+		return false
+	}
+	ext := strings.ToLower(filepath.Ext(dest))
+	return ext == ".md" || ext == ".markdown"
+}
+
+// rewriteLink maps a link to a local Markdown file onto the URL of its
+// published page. Fragments are translated from GitHub-style heading slugs to
+// the anchors Telegraph generates. Links that are not local Markdown files are
+// returned unchanged.
+func (c *mdConverter) rewriteLink(dest string) string {
+	if c.opts.Links == nil {
+		return dest
+	}
+
+	file, fragment, _ := strings.Cut(dest, "#")
+	if file == "" {
+		// Link within the current document
+		if fragment == "" {
+			return dest
+		}
+		return "#" + c.anchor(c.headings, fragment)
+	}
+	if !isMarkdownFile(file) {
+		return dest
+	}
+
+	if c.opts.BaseDir == "" {
+		file = resolvePath(c.opts.dir, file)
+	}
+	pageURL, ok := c.opts.Links(file)
+	if !ok {
+		c.unresolved = append(c.unresolved, file)
+		return dest
+	}
+	if fragment == "" {
+		return pageURL
+	}
+
+	headings, err := fileHeadings(file)
+	if err != nil {
+		return pageURL + "#" + fragment
+	}
+	return pageURL + "#" + c.anchor(headings, fragment)
+}
+
+// anchor returns the Telegraph anchor for a heading slug, or the slug itself
+// when no heading matches
+func (c *mdConverter) anchor(headings map[string]string, slug string) string {
+	if a, ok := headings[strings.ToLower(slug)]; ok {
+		return a
+	}
+	return slug
+}
+
+// fileHeadings parses a Markdown file and returns its heading anchors
+func fileHeadings(file string) (map[string]string, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	source := skipYAMLFrontMatter(content)
+	doc := parser.Parse(text.NewReader(source))
+	return headingAnchors(doc, source), nil
+}
+
+// headingAnchors maps the GitHub-style slug of every heading in doc to the
+// anchor Telegraph assigns to it, with repeated headings numbered as by
+// UniqueAnchor
+func headingAnchors(doc ast.Node, source []byte) map[string]string {
+	anchors := map[string]string{}
+	seen := map[string]int{}
+	taken := map[string]bool{}
+	c := &mdConverter{source: source}
+
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		h, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+
+		title := c.plainText(h)
+		slug := githubSlug(title)
+		if count := seen[slug]; count > 0 {
+			seen[slug]++
+			slug = fmt.Sprintf("%s-%d", slug, count)
+		} else {
+			seen[slug] = 1
+		}
+		if anchor := Anchor(title); anchor != "" {
+			anchors[slug] = UniqueAnchor(taken, anchor)
+		}
+		return ast.WalkSkipChildren, nil
+	})
+
+	return anchors
+}
+
+// githubSlug builds the fragment GitHub generates for a heading
+func githubSlug(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(title)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
@@ -1,29 +1,101 @@
+// Package markdown converts CommonMark (with GitHub Flavored Markdown
+// extensions) into telegraph nodes. HTML, AsciiDoc, Org-mode and
+// reStructuredText are supported through the same Converter interface.
+//
+// Parsing is done by goldmark, which follows the CommonMark specification,
+// and the resulting AST is mapped onto the small set of tags Telegraph
+// accepts. Constructs Telegraph cannot represent are handled as follows:
+//
+//   - Headings: level 1 becomes h3, levels 2 to 6 become h4.
+//   - Fenced and indented code blocks become pre > code. The info string
+//     (language) is dropped because Telegraph only allows href and src
+//     attributes.
+//   - Ordered list start numbers are dropped; lists always start at 1.
+//   - Images that form a paragraph on their own become a figure with the alt
+//     text as figcaption. Images inside running text stay inline img nodes.
+//   - Link and image titles are dropped.
+//   - GFM tables have no Telegraph equivalent. Each row becomes a paragraph
+//     with cells separated by " | ", and header cells are set in bold.
+//   - GFM task list checkboxes become the text "☐ " or "☑ ".
+//   - GFM strikethrough becomes s.
+//   - Raw HTML blocks and inline HTML are dropped, as a CommonMark renderer
+//     in safe mode would do.
+//   - Soft line breaks become a single space, hard line breaks become br.
 package markdown
 
 import (
 	"bytes"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
 	telegraph "source.toby3d.me/toby3d/telegraph/v2"
 	"gopkg.in/yaml.v3"
 	"golang.org/x/net/html/atom"
 )
 
+// Options controls how Markdown is converted to telegraph nodes
+type Options struct {
+	// BaseDir is the directory that relative image and link paths are
+	// resolved against. Paths are left as written when it is empty.
+	BaseDir string
+
+	// Links maps a local Markdown file referenced by a relative link to the
+	// URL of its published page. When set, such links are rewritten and
+	// links to files it does not know are reported as an
+	// *UnresolvedLinksError.
+	Links func(file string) (string, bool)
+
+	// UploadImage stores image data embedded in the source, such as notebook
+	// outputs, and returns its URL. Embedded images are skipped when it is
+	// nil.
+	UploadImage func(data []byte, contentType string) (string, error)
+
+	// Notebook holds the options for Jupyter notebooks
+	Notebook NotebookOptions
+
+	// dir is the directory of the parsed file, which Links targets are
+	// relative to
+	dir string
+}
+
 // Parse parses a markdown file and returns the content as telegraph nodes
 func Parse(filePath string) ([]telegraph.Node, error) {
-	content, err := os.ReadFile(filePath)
+	return ParseFile(filePath, Options{})
+}
+
+// ParseFile parses a markdown file with the given options
+func ParseFile(filePath string, opts Options) ([]telegraph.Node, error) {
+	f, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read markdown file: %v", err)
 	}
+	defer f.Close()
+
+	opts.dir = filepath.Dir(filePath)
+	return ParseReader(f, opts)
+}
+
+// ParseReader parses markdown from r and returns the content as telegraph nodes
+func ParseReader(r io.Reader, opts Options) ([]telegraph.Node, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read markdown: %v", err)
+	}
 
 	// Skip YAML front matter if present
 	content = skipYAMLFrontMatter(content)
 
-	// Simple markdown to telegraph nodes converter
-	nodes, err := markdownToNodes(string(content))
+	nodes, err := markdownToNodes(content, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -48,229 +120,395 @@ func skipYAMLFrontMatter(content []byte) []byte {
 	return parts[1]
 }
 
+// parser is shared by all conversions; goldmark parsers are safe for
+// concurrent use.
+var parser = goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser()
+
 // markdownToNodes converts markdown content to telegraph nodes
-func markdownToNodes(content string) ([]telegraph.Node, error) {
-	lines := strings.Split(content, "\n")
+func markdownToNodes(source []byte, opts Options) ([]telegraph.Node, error) {
+	doc := parser.Parse(text.NewReader(source))
+
+	c := &mdConverter{source: source, opts: opts}
+	if opts.Links != nil {
+		c.headings = headingAnchors(doc, source)
+	}
+
+	nodes := c.blocks(doc)
+	if len(c.unresolved) > 0 {
+		return nil, &UnresolvedLinksError{Links: c.unresolved}
+	}
+
+	return nodes, nil
+}
+
+// mdConverter walks a goldmark AST and builds telegraph nodes
+type mdConverter struct {
+	source []byte
+	opts   Options
+
+	// headings maps heading slugs of the current document to anchors
+	headings map[string]string
+	// unresolved collects links to unpublished files
+	unresolved []string
+}
+
+// blocks converts the block children of n
+func (c *mdConverter) blocks(n ast.Node) []telegraph.Node {
 	nodes := []telegraph.Node{}
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		nodes = append(nodes, c.block(child)...)
+	}
+	return nodes
+}
+
+// block converts a single block node. It returns a slice because some
+// constructs (tables) expand into several Telegraph blocks and others (raw
+// HTML) into none.
+func (c *mdConverter) block(n ast.Node) []telegraph.Node {
+	switch n := n.(type) {
+	case *ast.Heading:
+		tag := atom.H4
+		if n.Level == 1 {
+			tag = atom.H3
+		}
+		return []telegraph.Node{Element(tag, c.inlines(n)...)}
+
+	case *ast.Paragraph:
+		if fig, ok := c.figure(n); ok {
+			return []telegraph.Node{fig}
+		}
+		return []telegraph.Node{Element(atom.P, c.inlines(n)...)}
+
+	case *ast.TextBlock:
+		if !n.HasChildren() {
+			// Left behind by link reference definitions
+			return nil
+		}
+		return []telegraph.Node{Element(atom.P, c.inlines(n)...)}
+
+	case *ast.ThematicBreak:
+		return []telegraph.Node{Element(atom.Hr)}
+
+	case *ast.CodeBlock, *ast.FencedCodeBlock:
+		code := strings.TrimSuffix(c.lines(n), "\n")
+		return []telegraph.Node{Element(atom.Pre, Element(atom.Code, telegraph.Node{Text: code}))}
+
+	case *ast.Blockquote:
+		return []telegraph.Node{Element(atom.Blockquote, c.blocks(n)...)}
+
+	case *ast.List:
+		tag := atom.Ul
+		if n.IsOrdered() {
+			tag = atom.Ol
+		}
+		items := []telegraph.Node{}
+		for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+			items = append(items, Element(atom.Li, c.listItem(item)...))
+		}
+		return []telegraph.Node{Element(tag, items...)}
+
+	case *extast.Table:
+		return c.table(n)
 
-	// Process lines
-	var currentParagraph []string
-	var inCodeBlock bool
-	var codeContent []string
-	var codeLanguage string
-
-	flushParagraph := func() {
-		if len(currentParagraph) > 0 {
-			text := strings.Join(currentParagraph, " ")
-			if text = strings.TrimSpace(text); text != "" {
-				// Create paragraph node
-				pTag, _ := telegraph.NewTag(atom.P)
-				pElem := telegraph.NewNodeElement(pTag)
-				
-				// Create text node
-				textNode := telegraph.Node{
-					Text: text,
-				}
-				
-				// Add text node to paragraph
-				pElem.Children = append(pElem.Children, textNode)
-				
-				// Create the node with NodeElement
-				node := telegraph.Node{
-					Element: pElem,
-				}
-				
-				// Add to nodes
-				nodes = append(nodes, node)
+	case *ast.HTMLBlock:
+		return nil
+	}
+
+	// Unknown block types keep their text so no content is lost
+	if n.HasChildren() {
+		return c.blocks(n)
+	}
+	return nil
+}
+
+// listItem converts the content of a list item. Paragraphs are flattened into
+// inline content separated by line breaks, because Telegraph renders block
+// elements inside li poorly; nested lists and other blocks are kept.
+func (c *mdConverter) listItem(item ast.Node) []telegraph.Node {
+	nodes := []telegraph.Node{}
+	inlineSeen := false
+	for child := item.FirstChild(); child != nil; child = child.NextSibling() {
+		switch child.(type) {
+		case *ast.TextBlock, *ast.Paragraph:
+			if inlineSeen {
+				nodes = append(nodes, Element(atom.Br))
 			}
-			currentParagraph = []string{}
+			nodes = append(nodes, c.inlines(child)...)
+			inlineSeen = true
+		default:
+			nodes = append(nodes, c.block(child)...)
 		}
 	}
+	return nodes
+}
+
+// figure turns a paragraph consisting of a single image into a figure
+func (c *mdConverter) figure(p *ast.Paragraph) (telegraph.Node, bool) {
+	img, ok := p.FirstChild().(*ast.Image)
+	if !ok || img.NextSibling() != nil {
+		return telegraph.Node{}, false
+	}
+
+	children := []telegraph.Node{c.image(img)}
+	if alt := c.plainText(img); alt != "" {
+		children = append(children, Element(atom.Figcaption, telegraph.Node{Text: alt}))
+	}
+	return Element(atom.Figure, children...), true
+}
 
-	for _, line := range lines {
-		// Handle code blocks
-		if strings.HasPrefix(line, "```") {
-			if inCodeBlock {
-				// End of code block
-				inCodeBlock = false
-				code := strings.Join(codeContent, "\n")
-				
-				// Create pre tag
-				preTag, _ := telegraph.NewTag(atom.Pre)
-				preElem := telegraph.NewNodeElement(preTag)
-				
-				// Create code tag
-				codeTag, _ := telegraph.NewTag(atom.Code)
-				codeElem := telegraph.NewNodeElement(codeTag)
-				
-				// We can't set language attribute as Telegraph only supports href and src
-				// Just keep track of the language for reference
-				_ = codeLanguage
-				
-				// Add code text as child of code element
-				codeElem.Children = append(codeElem.Children, telegraph.Node{Text: code})
-				
-				// Add code element as child of pre element
-				preElem.Children = append(preElem.Children, telegraph.Node{Element: codeElem})
-				
-				// Create the node with NodeElement and add to nodes
-				nodes = append(nodes, telegraph.Node{Element: preElem})
-				
-				codeContent = []string{}
-				codeLanguage = ""
-				continue
+// table flattens a GFM table into one paragraph per row
+func (c *mdConverter) table(t *extast.Table) []telegraph.Node {
+	nodes := []telegraph.Node{}
+	for row := t.FirstChild(); row != nil; row = row.NextSibling() {
+		_, header := row.(*extast.TableHeader)
+
+		cells := []telegraph.Node{}
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			if cell.PreviousSibling() != nil {
+				cells = append(cells, telegraph.Node{Text: " | "})
+			}
+			content := c.inlines(cell)
+			if header {
+				cells = append(cells, Element(atom.Strong, content...))
 			} else {
-				// Start of code block
-				flushParagraph()
-				inCodeBlock = true
-				codeLanguage = strings.TrimSpace(strings.TrimPrefix(line, "```"))
-				continue
+				cells = append(cells, content...)
 			}
 		}
+		nodes = append(nodes, Element(atom.P, mergeText(cells)...))
+	}
+	return nodes
+}
 
-		if inCodeBlock {
-			codeContent = append(codeContent, line)
-			continue
+// inlines converts the inline children of n
+func (c *mdConverter) inlines(n ast.Node) []telegraph.Node {
+	nodes := []telegraph.Node{}
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		nodes = append(nodes, c.inline(child)...)
+	}
+	return mergeText(nodes)
+}
+
+// inline converts a single inline node
+func (c *mdConverter) inline(n ast.Node) []telegraph.Node {
+	switch n := n.(type) {
+	case *ast.Text:
+		value := n.Value(c.source)
+		if !n.IsRaw() {
+			value = unescape(value)
 		}
+		nodes := []telegraph.Node{{Text: string(value)}}
+		if n.HardLineBreak() {
+			nodes = append(nodes, Element(atom.Br))
+		} else if n.SoftLineBreak() {
+			nodes = append(nodes, telegraph.Node{Text: " "})
+		}
+		return nodes
 
-		// Handle headers
-		if strings.HasPrefix(line, "# ") {
-			flushParagraph()
-			text := strings.TrimSpace(strings.TrimPrefix(line, "# "))
-			
-			// Create h3 tag (Telegraph doesn't support h1)
-			h3Tag, _ := telegraph.NewTag(atom.H3)
-			h3Elem := telegraph.NewNodeElement(h3Tag)
-			
-			// Add text as child
-			h3Elem.Children = append(h3Elem.Children, telegraph.Node{Text: text})
-			
-			// Create node with NodeElement and add to nodes
-			nodes = append(nodes, telegraph.Node{Element: h3Elem})
-			continue
+	case *ast.String:
+		value := n.Value
+		if !n.IsRaw() {
+			value = unescape(value)
 		}
+		return []telegraph.Node{{Text: string(value)}}
 
-		if strings.HasPrefix(line, "## ") {
-			flushParagraph()
-			text := strings.TrimSpace(strings.TrimPrefix(line, "## "))
-			
-			// Create h4 tag (Telegraph doesn't support h2)
-			h4Tag, _ := telegraph.NewTag(atom.H4)
-			h4Elem := telegraph.NewNodeElement(h4Tag)
-			
-			// Add text as child
-			h4Elem.Children = append(h4Elem.Children, telegraph.Node{Text: text})
-			
-			// Create node with NodeElement and add to nodes
-			nodes = append(nodes, telegraph.Node{Element: h4Elem})
-			continue
+	case *ast.CodeSpan:
+		return []telegraph.Node{Element(atom.Code, telegraph.Node{Text: c.codeSpanText(n)})}
+
+	case *ast.Emphasis:
+		tag := atom.Em
+		if n.Level >= 2 {
+			tag = atom.Strong
 		}
+		return []telegraph.Node{Element(tag, c.inlines(n)...)}
 
-		if strings.HasPrefix(line, "### ") {
-			flushParagraph()
-			text := strings.TrimSpace(strings.TrimPrefix(line, "### "))
-			
-			// Create h4 tag
-			h4Tag, _ := telegraph.NewTag(atom.H4)
-			h4Elem := telegraph.NewNodeElement(h4Tag)
-			
-			// Add text as child
-			h4Elem.Children = append(h4Elem.Children, telegraph.Node{Text: text})
-			
-			// Create node with NodeElement and add to nodes
-			nodes = append(nodes, telegraph.Node{Element: h4Elem})
-			continue
+	case *extast.Strikethrough:
+		return []telegraph.Node{Element(atom.S, c.inlines(n)...)}
+
+	case *ast.Link:
+		a := Element(atom.A, c.inlines(n)...)
+		setAttr(a, "href", c.rewriteLink(c.resolve(string(unescape(n.Destination)))))
+		return []telegraph.Node{a}
+
+	case *ast.AutoLink:
+		href := string(n.URL(c.source))
+		if n.AutoLinkType == ast.AutoLinkEmail && !strings.HasPrefix(strings.ToLower(href), "mailto:") {
+			href = "mailto:" + href
 		}
+		a := Element(atom.A, telegraph.Node{Text: string(n.Label(c.source))})
+		setAttr(a, "href", href)
+		return []telegraph.Node{a}
 
-		// Handle lists
-		if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") {
-			flushParagraph()
-			text := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(line, "- "), "* "))
-			
-			// Create li tag
-			liTag, _ := telegraph.NewTag(atom.Li)
-			liElem := telegraph.NewNodeElement(liTag)
-			
-			// Add text as child
-			liElem.Children = append(liElem.Children, telegraph.Node{Text: text})
-			
-			// Create li node
-			liNode := telegraph.Node{Element: liElem}
-			
-			// Check if previous node is a ul element
-			var isUl bool
-			if len(nodes) > 0 && nodes[len(nodes)-1].Element != nil {
-				tagAtom := nodes[len(nodes)-1].Element.Tag.Atom()
-				if tagAtom == atom.Ul {
-					isUl = true
-				}
-			}
-			
-			if isUl {
-				// Add to existing ul element
-				nodes[len(nodes)-1].Element.Children = append(nodes[len(nodes)-1].Element.Children, liNode)
-			} else {
-				// Create new ul tag
-				ulTag, _ := telegraph.NewTag(atom.Ul)
-				ulElem := telegraph.NewNodeElement(ulTag)
-				
-				// Add li as child
-				ulElem.Children = append(ulElem.Children, liNode)
-				
-				// Create ul node and add to nodes
-				nodes = append(nodes, telegraph.Node{Element: ulElem})
+	case *ast.Image:
+		return []telegraph.Node{c.image(n)}
+
+	case *extast.TaskCheckBox:
+		if n.IsChecked {
+			return []telegraph.Node{{Text: "☑ "}}
+		}
+		return []telegraph.Node{{Text: "☐ "}}
+
+	case *ast.RawHTML:
+		return nil
+	}
+
+	return c.inlines(n)
+}
+
+// image converts an image node to an img element
+func (c *mdConverter) image(n *ast.Image) telegraph.Node {
+	img := Element(atom.Img)
+	setAttr(img, "src", c.resolve(string(unescape(n.Destination))))
+	return img
+}
+
+// resolve joins a relative link or image path with the base directory
+func (c *mdConverter) resolve(dest string) string {
+	return resolvePath(c.opts.BaseDir, dest)
+}
+
+// resolvePath joins a relative link or image path with baseDir. URLs,
+// absolute paths and fragment-only links are returned unchanged.
+func resolvePath(baseDir, dest string) string {
+	if baseDir == "" || dest == "" || strings.HasPrefix(dest, "#") || filepath.IsAbs(dest) {
+		return dest
+	}
+	if u, err := url.Parse(dest); err != nil || u.Scheme != "" || u.Host != "" {
+		return dest
+	}
+	return filepath.Join(baseDir, dest)
+}
+
+// codeSpanText returns the literal content of a code span
+func (c *mdConverter) codeSpanText(n *ast.CodeSpan) string {
+	var buf bytes.Buffer
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		if t, ok := child.(*ast.Text); ok {
+			value := t.Segment.Value(c.source)
+			if bytes.HasSuffix(value, []byte("\n")) {
+				value = append(value[:len(value)-1:len(value)-1], ' ')
 			}
-			continue
+			buf.Write(value)
 		}
+	}
+	return buf.String()
+}
+
+// plainText returns the text content of an inline subtree, used for alt text
+func (c *mdConverter) plainText(n ast.Node) string {
+	var buf strings.Builder
+	for _, node := range c.inlines(n) {
+		buf.WriteString(Text(node))
+	}
+	return buf.String()
+}
+
+// lines returns the raw lines of a block node
+func (c *mdConverter) lines(n ast.Node) string {
+	var buf bytes.Buffer
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		buf.Write(line.Value(c.source))
+	}
+	return buf.String()
+}
+
+// unescape resolves backslash escapes and entity references
+func unescape(value []byte) []byte {
+	value = util.UnescapePunctuations(value)
+	value = util.ResolveNumericReferences(value)
+	return util.ResolveEntityNames(value)
+}
 
-		// Empty line - flush paragraph
-		if strings.TrimSpace(line) == "" {
-			flushParagraph()
+// mergeText joins adjacent text nodes produced by the inline parser
+func mergeText(nodes []telegraph.Node) []telegraph.Node {
+	merged := make([]telegraph.Node, 0, len(nodes))
+	for _, node := range nodes {
+		last := len(merged) - 1
+		if node.Element == nil && last >= 0 && merged[last].Element == nil {
+			merged[last].Text += node.Text
 			continue
 		}
-
-		// Normal paragraph text
-		currentParagraph = append(currentParagraph, line)
+		merged = append(merged, node)
 	}
+	return merged
+}
+
+// FrontMatter holds the YAML front matter fields telegraphcl understands
+type FrontMatter struct {
+	Title string `yaml:"title"`
+	// Path is the telegra.ph path of the published page
+	Path string `yaml:"path"`
+	// Tags are used to filter and group pages and for tag pages
+	Tags TagList `yaml:"tags"`
+	// Category is a single grouping for the page
+	Category string `yaml:"category"`
+}
 
-	// Flush any remaining paragraph
-	flushParagraph()
+// TagList is a list of tags, written either as a YAML list or as a comma
+// separated string
+type TagList []string
 
-	return nodes, nil
+func (t *TagList) UnmarshalYAML(value *yaml.Node) error {
+	var list []string
+	if value.Kind == yaml.ScalarNode {
+		var s string
+		if err := value.Decode(&s); err != nil {
+			return err
+		}
+		list = strings.Split(s, ",")
+	} else if err := value.Decode(&list); err != nil {
+		return err
+	}
+
+	*t = nil
+	for _, tag := range list {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			*t = append(*t, tag)
+		}
+	}
+	return nil
 }
 
-// ReadTitle reads the title from a markdown file's front matter
-func ReadTitle(r io.Reader) (string, error) {
+// ReadFrontMatter reads the front matter of a markdown document
+func ReadFrontMatter(r io.Reader) (FrontMatter, error) {
+	var frontMatter FrontMatter
+
 	var buf bytes.Buffer
 	if _, err := buf.ReadFrom(r); err != nil {
-		return "", err
+		return frontMatter, err
 	}
-	
+
 	content := buf.Bytes()
-	
+
 	// Check if content starts with "---" (YAML front matter)
 	if !bytes.HasPrefix(content, []byte("---\n")) {
-		return "", fmt.Errorf("no front matter found")
+		return frontMatter, fmt.Errorf("no front matter found")
 	}
-	
+
 	// Find the closing "---"
 	parts := bytes.SplitN(content[4:], []byte("---\n"), 2)
 	if len(parts) != 2 {
-		return "", fmt.Errorf("invalid front matter format")
+		return frontMatter, fmt.Errorf("invalid front matter format")
 	}
-	
-	// Parse front matter
-	var frontMatter struct {
-		Title string `yaml:"title"`
-	}
-	
+
 	if err := yaml.Unmarshal(parts[0], &frontMatter); err != nil {
-		return "", fmt.Errorf("failed to parse front matter: %v", err)
+		return frontMatter, fmt.Errorf("failed to parse front matter: %v", err)
+	}
+
+	return frontMatter, nil
+}
+
+// ReadTitle reads the title from a markdown file's front matter
+func ReadTitle(r io.Reader) (string, error) {
+	frontMatter, err := ReadFrontMatter(r)
+	if err != nil {
+		return "", err
 	}
-	
+
 	if frontMatter.Title == "" {
 		return "", fmt.Errorf("no title found in front matter")
 	}
-	
+
 	return frontMatter.Title, nil
 }
@@ -0,0 +1,174 @@
+package markdown
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+)
+
+// txt returns a text node
+func txt(s string) telegraph.Node {
+	return telegraph.Node{Text: s}
+}
+
+// withAttr returns n with an attribute set
+func withAttr(n telegraph.Node, key, value string) telegraph.Node {
+	setAttr(n, key, value)
+	return n
+}
+
+// dump formats nodes as HTML-like text for failure messages
+func dump(nodes []telegraph.Node) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		if n.Element == nil {
+			fmt.Fprintf(&b, "%q", n.Text)
+			continue
+		}
+		b.WriteString("<" + n.Element.Tag.Atom().String())
+		for _, key := range []string{"href", "src"} {
+			if value, ok := n.Element.Attrs[key]; ok {
+				fmt.Fprintf(&b, " %s=%q", key, value)
+			}
+		}
+		b.WriteString(">" + dump(n.Element.Children) + "</" + n.Element.Tag.Atom().String() + ">")
+	}
+	return b.String()
+}
+
+func TestParseReaderSpecExamples(t *testing.T) {
+	p := func(children ...telegraph.Node) telegraph.Node { return Element(atom.P, children...) }
+	a := func(href string, children ...telegraph.Node) telegraph.Node {
+		return withAttr(Element(atom.A, children...), "href", href)
+	}
+	li := func(children ...telegraph.Node) telegraph.Node { return Element(atom.Li, children...) }
+	code := func(s string) telegraph.Node { return Element(atom.Code, txt(s)) }
+	pre := func(s string) telegraph.Node { return Element(atom.Pre, code(s)) }
+	br := Element(atom.Br)
+
+	tests := []struct {
+		name     string
+		markdown string
+		want     []telegraph.Node
+	}{
+		// Headings
+		{"ATX h1", "# foo", []telegraph.Node{Element(atom.H3, txt("foo"))}},
+		{"ATX h2", "## foo", []telegraph.Node{Element(atom.H4, txt("foo"))}},
+		{"ATX h6", "###### foo", []telegraph.Node{Element(atom.H4, txt("foo"))}},
+		{"ATX closing sequence", "# foo ##", []telegraph.Node{Element(atom.H3, txt("foo"))}},
+		{"setext h1", "Foo *bar*\n=========", []telegraph.Node{Element(atom.H3, txt("Foo "), Element(atom.Em, txt("bar")))}},
+		{"setext h2", "Foo\n---", []telegraph.Node{Element(atom.H4, txt("Foo"))}},
+		{"seven hashes", "####### foo", []telegraph.Node{p(txt("####### foo"))}},
+
+		// Emphasis
+		{"em", "*foo bar*", []telegraph.Node{p(Element(atom.Em, txt("foo bar")))}},
+		{"em underscore", "_foo bar_", []telegraph.Node{p(Element(atom.Em, txt("foo bar")))}},
+		{"strong", "**foo bar**", []telegraph.Node{p(Element(atom.Strong, txt("foo bar")))}},
+		{"intraword em", "foo*bar*", []telegraph.Node{p(txt("foo"), Element(atom.Em, txt("bar")))}},
+		{"intraword underscore", "foo_bar_", []telegraph.Node{p(txt("foo_bar_"))}},
+		{"space after delimiter", "a * foo bar*", []telegraph.Node{p(txt("a * foo bar*"))}},
+		{"em in strong", "**foo *bar* baz**", []telegraph.Node{p(Element(atom.Strong, txt("foo "), Element(atom.Em, txt("bar")), txt(" baz")))}},
+		{"strong em", "***foo***", []telegraph.Node{p(Element(atom.Em, Element(atom.Strong, txt("foo"))))}},
+		{"strikethrough", "~~foo~~", []telegraph.Node{p(Element(atom.S, txt("foo")))}},
+		{"escaped delimiter", `\*not emphasized*`, []telegraph.Node{p(txt("*not emphasized*"))}},
+
+		// Links
+		{"inline link", "[link](/uri)", []telegraph.Node{p(a("/uri", txt("link")))}},
+		{"link title dropped", `[link](/url "title")`, []telegraph.Node{p(a("/url", txt("link")))}},
+		{"empty destination", "[link]()", []telegraph.Node{p(a("", txt("link")))}},
+		{"pointy brackets", "[link](<foo bar>)", []telegraph.Node{p(a("foo bar", txt("link")))}},
+		{"emphasis in text", "[*foo* bar](/uri)", []telegraph.Node{p(a("/uri", Element(atom.Em, txt("foo")), txt(" bar")))}},
+		{"reference link", "[foo]\n\n[foo]: /url", []telegraph.Node{p(a("/url", txt("foo")))}},
+		{"case-insensitive reference", "[Foo][BAR]\n\n[bar]: /url", []telegraph.Node{p(a("/url", txt("Foo")))}},
+		{"autolink", "<https://foo.bar/baz>", []telegraph.Node{p(a("https://foo.bar/baz", txt("https://foo.bar/baz")))}},
+		{"email autolink", "<foo@bar.example.com>", []telegraph.Node{p(a("mailto:foo@bar.example.com", txt("foo@bar.example.com")))}},
+		{"entity in destination", "[link](/f&ouml;&ouml;)", []telegraph.Node{p(a("/föö", txt("link")))}},
+
+		// Images
+		{"image paragraph", "![foo](/url)", []telegraph.Node{Element(atom.Figure, withAttr(Element(atom.Img), "src", "/url"), Element(atom.Figcaption, txt("foo")))}},
+		{"inline image", "a ![foo](/url)", []telegraph.Node{p(txt("a "), withAttr(Element(atom.Img), "src", "/url"))}},
+
+		// Lists
+		{"bullet list", "- foo\n- bar", []telegraph.Node{Element(atom.Ul, li(txt("foo")), li(txt("bar")))}},
+		{"ordered start dropped", "3. foo\n4. bar", []telegraph.Node{Element(atom.Ol, li(txt("foo")), li(txt("bar")))}},
+		{"nested lists", "- a\n  - b\n    - c\n- d", []telegraph.Node{Element(atom.Ul,
+			li(txt("a"), Element(atom.Ul, li(txt("b"), Element(atom.Ul, li(txt("c")))))),
+			li(txt("d")))}},
+		{"ordered in bullet", "- a\n  1. b\n  2. c", []telegraph.Node{Element(atom.Ul,
+			li(txt("a"), Element(atom.Ol, li(txt("b")), li(txt("c")))))}},
+		{"loose item", "- a\n\n  b", []telegraph.Node{Element(atom.Ul, li(txt("a"), br, txt("b")))}},
+		{"change of marker", "- foo\n+ bar", []telegraph.Node{Element(atom.Ul, li(txt("foo"))), Element(atom.Ul, li(txt("bar")))}},
+		{"task list", "- [ ] foo\n- [x] bar", []telegraph.Node{Element(atom.Ul, li(txt("☐ foo")), li(txt("☑ bar")))}},
+
+		// Code spans
+		{"code span", "`foo`", []telegraph.Node{p(code("foo"))}},
+		{"backtick in code span", "`` foo ` bar ``", []telegraph.Node{p(code("foo ` bar"))}},
+		{"code span line ending", "`foo\nbar`", []telegraph.Node{p(code("foo bar"))}},
+		{"no escapes in code span", "`foo\\`bar`", []telegraph.Node{p(code(`foo\`), txt("bar`"))}},
+
+		// Code blocks
+		{"indented code", "    a simple\n      indented code block", []telegraph.Node{pre("a simple\n  indented code block")}},
+		{"backtick fence", "```\n<\n >\n```", []telegraph.Node{pre("<\n >")}},
+		{"tilde fence", "~~~\naaa\n~~~", []telegraph.Node{pre("aaa")}},
+		{"info string dropped", "```ruby\ndef foo(x)\n  return 3\nend\n```", []telegraph.Node{pre("def foo(x)\n  return 3\nend")}},
+		{"fence in list", "- foo\n\n  ```\n  bar\n  ```", []telegraph.Node{Element(atom.Ul, li(txt("foo"), pre("bar")))}},
+
+		// Block quotes
+		{"block quote", "> # Foo\n> bar\n> baz", []telegraph.Node{Element(atom.Blockquote, Element(atom.H3, txt("Foo")), p(txt("bar baz")))}},
+		{"lazy continuation", "> bar\nbaz", []telegraph.Node{Element(atom.Blockquote, p(txt("bar baz")))}},
+		{"nested block quote", "> > foo", []telegraph.Node{Element(atom.Blockquote, Element(atom.Blockquote, p(txt("foo"))))}},
+		{"list in block quote", "> - foo", []telegraph.Node{Element(atom.Blockquote, Element(atom.Ul, li(txt("foo"))))}},
+
+		// Line breaks
+		{"hard break spaces", "foo  \nbar", []telegraph.Node{p(txt("foo"), br, txt("bar"))}},
+		{"hard break backslash", "foo\\\nbar", []telegraph.Node{p(txt("foo"), br, txt("bar"))}},
+		{"hard break in emphasis", "*foo  \nbar*", []telegraph.Node{p(Element(atom.Em, txt("foo"), br, txt("bar")))}},
+		{"soft break", "foo\nbaz", []telegraph.Node{p(txt("foo baz"))}},
+		{"no hard break at end", "foo  ", []telegraph.Node{p(txt("foo"))}},
+
+		// HTML
+		{"HTML block", "<div>\n*hello*\n</div>", []telegraph.Node{}},
+		{"HTML block between paragraphs", "foo\n\n<table><tr><td>\nbar\n</td></tr></table>\n\nbaz", []telegraph.Node{p(txt("foo")), p(txt("baz"))}},
+		{"HTML comment", "<!-- foo -->\n\nbar", []telegraph.Node{p(txt("bar"))}},
+		{"inline HTML", "foo <span>bar</span>", []telegraph.Node{p(txt("foo bar"))}},
+
+		// Other blocks and inlines
+		{"thematic break", "***\n---\n___", []telegraph.Node{Element(atom.Hr), Element(atom.Hr), Element(atom.Hr)}},
+		{"entities", "&amp; &copy; &#35;", []telegraph.Node{p(txt("& © #"))}},
+		{"table", "| a | b |\n| - | - |\n| c | d |", []telegraph.Node{
+			p(Element(atom.Strong, txt("a")), txt(" | "), Element(atom.Strong, txt("b"))),
+			p(txt("c | d"))}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseReader(strings.NewReader(tt.markdown), Options{})
+			if err != nil {
+				t.Fatalf("ParseReader(%q) failed: %v", tt.markdown, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseReader(%q)\n got: %s\nwant: %s", tt.markdown, dump(got), dump(tt.want))
+			}
+		})
+	}
+}
+
+func TestParseReaderBaseDir(t *testing.T) {
+	got, err := ParseReader(strings.NewReader("[a](other.md) ![b](img.png) [c](https://example.com/x.md)"), Options{BaseDir: "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []telegraph.Node{Element(atom.P,
+		withAttr(Element(atom.A, txt("a")), "href", "docs/other.md"),
+		txt(" "),
+		withAttr(Element(atom.Img), "src", "docs/img.png"),
+		txt(" "),
+		withAttr(Element(atom.A, txt("c")), "href", "https://example.com/x.md"))}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %s\nwant: %s", dump(got), dump(want))
+	}
+}
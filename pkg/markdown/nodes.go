@@ -0,0 +1,63 @@
+package markdown
+
+import (
+	"strconv"
+	"strings"
+
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+)
+
+// Element creates a telegraph element node with the given children
+func Element(tag atom.Atom, children ...telegraph.Node) telegraph.Node {
+	t, _ := telegraph.NewTag(tag)
+	elem := telegraph.NewNodeElement(t)
+	elem.Children = append(elem.Children, children...)
+	return telegraph.Node{Element: elem}
+}
+
+// Link creates an a element linking to href
+func Link(href string, children ...telegraph.Node) telegraph.Node {
+	a := Element(atom.A, children...)
+	setAttr(a, "href", href)
+	return a
+}
+
+// setAttr sets an attribute on an element node. Telegraph only accepts href
+// and src.
+func setAttr(n telegraph.Node, key, value string) {
+	if n.Element.Attrs == nil {
+		n.Element.Attrs = make(map[string]string)
+	}
+	n.Element.Attrs[key] = value
+}
+
+// Text returns the concatenated text of a node and its children
+func Text(n telegraph.Node) string {
+	if n.Element == nil {
+		return n.Text
+	}
+	var buf strings.Builder
+	for _, child := range n.Element.Children {
+		buf.WriteString(Text(child))
+	}
+	return buf.String()
+}
+
+// Anchor returns the anchor Telegraph assigns to h3 and h4 headings, which is
+// the heading text with whitespace replaced by dashes
+func Anchor(title string) string {
+	return strings.Join(strings.Fields(title), "-")
+}
+
+// UniqueAnchor returns anchor, or anchor with the first free suffix from -2
+// on when it is taken, and marks the result as taken. Repeated headings get
+// their anchors this way both in links and in exported HTML.
+func UniqueAnchor(taken map[string]bool, anchor string) string {
+	unique := anchor
+	for i := 2; taken[unique]; i++ {
+		unique = anchor + "-" + strconv.Itoa(i)
+	}
+	taken[unique] = true
+	return unique
+}
@@ -0,0 +1,167 @@
+package markdown
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+)
+
+// NotebookOptions controls how Jupyter notebooks are converted
+type NotebookOptions struct {
+	// HideInputs leaves out the source of code cells
+	HideInputs bool
+	// HideOutputs leaves out the outputs of code cells
+	HideOutputs bool
+	// MaxOutputLines truncates text outputs longer than this; 0 keeps all
+	MaxOutputLines int
+}
+
+// ansiEscape matches terminal colour codes found in error tracebacks
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// notebook is the subset of the nbformat 4 document used for conversion
+type notebook struct {
+	Cells []notebookCell `json:"cells"`
+}
+
+type notebookCell struct {
+	CellType string           `json:"cell_type"`
+	Source   multiline        `json:"source"`
+	Outputs  []notebookOutput `json:"outputs"`
+}
+
+type notebookOutput struct {
+	OutputType string               `json:"output_type"`
+	Text       multiline            `json:"text"`
+	Data       map[string]multiline `json:"data"`
+	Traceback  []string             `json:"traceback"`
+}
+
+// multiline is a notebook string, stored either as a string or as a list of
+// lines
+type multiline string
+
+func (m *multiline) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*m = multiline(s)
+		return nil
+	}
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err != nil {
+		return err
+	}
+	*m = multiline(strings.Join(lines, ""))
+	return nil
+}
+
+// ParseNotebook converts a Jupyter notebook to telegraph nodes. Markdown
+// cells are converted as Markdown, code cells become pre > code, text outputs
+// become pre, and PNG and JPEG outputs are uploaded with Options.UploadImage
+// and embedded as figures. Image outputs are skipped when UploadImage is nil.
+func ParseNotebook(r io.Reader, opts Options) ([]telegraph.Node, error) {
+	var nb notebook
+	if err := json.NewDecoder(r).Decode(&nb); err != nil {
+		return nil, fmt.Errorf("failed to parse notebook: %v", err)
+	}
+
+	nodes := []telegraph.Node{}
+	for i, cell := range nb.Cells {
+		switch cell.CellType {
+		case "markdown":
+			cellNodes, err := markdownToNodes([]byte(cell.Source), opts)
+			if err != nil {
+				return nil, fmt.Errorf("cell %d: %v", i+1, err)
+			}
+			nodes = append(nodes, cellNodes...)
+
+		case "code":
+			if !opts.Notebook.HideInputs && strings.TrimSpace(string(cell.Source)) != "" {
+				nodes = append(nodes, codeNode(strings.TrimRight(string(cell.Source), "\n")))
+			}
+			if opts.Notebook.HideOutputs {
+				continue
+			}
+			for j, output := range cell.Outputs {
+				outputNodes, err := notebookOutputNodes(output, opts)
+				if err != nil {
+					return nil, fmt.Errorf("cell %d, output %d: %v", i+1, j+1, err)
+				}
+				nodes = append(nodes, outputNodes...)
+			}
+
+		case "raw":
+			if text := strings.TrimSpace(string(cell.Source)); text != "" {
+				nodes = append(nodes, Element(atom.P, telegraph.Node{Text: text}))
+			}
+		}
+	}
+
+	return nodes, nil
+}
+
+// notebookOutputNodes converts a single cell output
+func notebookOutputNodes(output notebookOutput, opts Options) ([]telegraph.Node, error) {
+	switch output.OutputType {
+	case "stream":
+		return textOutput(string(output.Text), opts), nil
+
+	case "error":
+		return textOutput(ansiEscape.ReplaceAllString(strings.Join(output.Traceback, "\n"), ""), opts), nil
+
+	case "execute_result", "display_data":
+		for _, contentType := range []string{"image/png", "image/jpeg"} {
+			data, ok := output.Data[contentType]
+			if !ok {
+				continue
+			}
+			if opts.UploadImage == nil {
+				return nil, nil
+			}
+			img, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(data), "\n", ""))
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode %s output: %v", contentType, err)
+			}
+			src, err := opts.UploadImage(img, contentType)
+			if err != nil {
+				return nil, err
+			}
+			return []telegraph.Node{figureNode(src, "")}, nil
+		}
+		if text, ok := output.Data["text/markdown"]; ok {
+			return markdownToNodes([]byte(text), opts)
+		}
+		if text, ok := output.Data["text/plain"]; ok {
+			return textOutput(string(text), opts), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// textOutput renders a text output as pre, truncated to MaxOutputLines
+func textOutput(text string, opts Options) []telegraph.Node {
+	text = strings.TrimRight(text, "\n")
+	if text == "" {
+		return nil
+	}
+
+	if max := opts.Notebook.MaxOutputLines; max > 0 {
+		lines := strings.Split(text, "\n")
+		if len(lines) > max {
+			text = strings.Join(lines[:max], "\n") + fmt.Sprintf("\n… (%d more lines)", len(lines)-max)
+		}
+	}
+
+	return []telegraph.Node{Element(atom.Pre, telegraph.Node{Text: text})}
+}
+
+func init() {
+	Register("notebook", ConverterFunc(ParseNotebook), ".ipynb")
+}
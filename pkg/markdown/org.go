@@ -0,0 +1,249 @@
+package markdown
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	orgHeading  = regexp.MustCompile(`^(\*+)\s+(.*?)(?:\s+:[\w@#%:]+:)?\s*$`)
+	orgListItem = regexp.MustCompile(`^(\s*)([-+]|\s\*|\d+[.)])\s+(?:\[([ Xx-])\]\s+)?(.*)$`)
+	orgKeyword  = regexp.MustCompile(`^#\+(\w+):`)
+	orgBegin    = regexp.MustCompile(`(?i)^#\+begin_(\w+)`)
+	orgLink     = regexp.MustCompile(`^\[\[([^\]]+)\](?:\[([^\]]+)\])?\]`)
+	orgTodo     = regexp.MustCompile(`^(TODO|DONE)\s+`)
+)
+
+// ParseOrg converts a practical subset of Org-mode to telegraph nodes:
+// headlines, paragraphs, plain lists with checkboxes, source, example and
+// quote blocks, fixed-width lines, tables, links, images and basic inline
+// markup. Keywords, comments, drawers and headline tags are dropped.
+func ParseOrg(r io.Reader, opts Options) ([]telegraph.Node, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &orgParser{opts: opts}
+	p.syntax = inlineSyntax{
+		spans: []span{
+			{open: "*", close: "*", tag: atom.Strong},
+			{open: "/", close: "/", tag: atom.Em},
+			{open: "_", close: "_", tag: atom.U},
+			{open: "+", close: "+", tag: atom.S},
+			{open: "=", close: "=", tag: atom.Code, literal: true},
+			{open: "~", close: "~", tag: atom.Code, literal: true},
+		},
+		link: p.link,
+	}
+
+	return p.blocks(lines), nil
+}
+
+// orgParser converts Org-mode lines to telegraph nodes
+type orgParser struct {
+	opts   Options
+	syntax inlineSyntax
+}
+
+// blocks converts a sequence of lines to block nodes
+func (p *orgParser) blocks(lines []string) []telegraph.Node {
+	b := &blockBuilder{inline: p.syntax.parse}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			b.flush()
+
+		case orgBegin.MatchString(trimmed):
+			kind := strings.ToLower(orgBegin.FindStringSubmatch(trimmed)[1])
+			body, end := orgBlock(lines, i, kind)
+			switch kind {
+			case "src", "example", "verse":
+				b.add(codeNode(strings.Join(dedent(body), "\n")))
+			case "quote":
+				b.add(Element(atom.Blockquote, p.blocks(body)...))
+			case "comment":
+			default:
+				b.add(p.blocks(body)...)
+			}
+			i = end
+
+		case orgKeyword.MatchString(trimmed), trimmed == "#", strings.HasPrefix(trimmed, "# "):
+			// Keywords and comments
+
+		case trimmed == ":PROPERTIES:" || trimmed == ":LOGBOOK:":
+			b.flush()
+			for i < len(lines) && strings.TrimSpace(lines[i]) != ":END:" {
+				i++
+			}
+
+		case orgHeading.MatchString(line):
+			m := orgHeading.FindStringSubmatch(line)
+			title := orgTodo.ReplaceAllString(m[2], "")
+			b.add(headingNode(len(m[1]), p.syntax.parse(title)))
+
+		case len(trimmed) >= 5 && strings.Count(trimmed, "-") == len(trimmed):
+			b.add(Element(atom.Hr))
+
+		case trimmed == ":" || strings.HasPrefix(trimmed, ": "):
+			// Fixed-width area
+			var code []string
+			for ; i < len(lines); i++ {
+				t := strings.TrimSpace(lines[i])
+				if t != ":" && !strings.HasPrefix(t, ": ") {
+					break
+				}
+				code = append(code, strings.TrimPrefix(strings.TrimPrefix(t, ":"), " "))
+			}
+			b.add(codeNode(strings.Join(code, "\n")))
+			i--
+
+		case strings.HasPrefix(trimmed, "|"):
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|"); i++ {
+				if row, ok := p.tableRow(lines[i]); ok {
+					b.add(row)
+				}
+			}
+			i--
+
+		case p.isImageLine(trimmed):
+			m := orgLink.FindStringSubmatch(trimmed)
+			b.add(figureNode(p.target(m[1]), ""))
+
+		case orgListItem.MatchString(line):
+			list, end := p.list(lines, i)
+			b.add(list)
+			i = end - 1
+
+		default:
+			b.paragraph = append(b.paragraph, line)
+		}
+	}
+
+	return b.result()
+}
+
+// list reads a plain list starting at lines[start]. Nested items are
+// flattened.
+func (p *orgParser) list(lines []string, start int) (telegraph.Node, int) {
+	ordered := isOrderedMarker(orgListItem.FindStringSubmatch(lines[start])[2])
+
+	var items [][]telegraph.Node
+	var item []string
+	var checkbox string
+	flush := func() {
+		if item == nil {
+			return
+		}
+		nodes := p.syntax.parse(joinParagraph(item))
+		if checkbox != "" {
+			nodes = append([]telegraph.Node{{Text: checkbox}}, nodes...)
+		}
+		items = append(items, mergeText(nodes))
+		item = nil
+	}
+
+	i := start
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if m := orgListItem.FindStringSubmatch(line); m != nil {
+			if isOrderedMarker(m[2]) != ordered {
+				break
+			}
+			flush()
+			item = []string{m[4]}
+			checkbox = orgCheckbox(m[3])
+			continue
+		}
+		if strings.TrimSpace(line) == "" || indentation(line) == 0 {
+			break
+		}
+		item = append(item, line)
+	}
+	flush()
+
+	return listNode(ordered, items), i
+}
+
+// tableRow converts a table row to a paragraph; rule lines are skipped
+func (p *orgParser) tableRow(line string) (telegraph.Node, bool) {
+	line = strings.Trim(strings.TrimSpace(line), "|")
+	if strings.HasPrefix(line, "-") {
+		return telegraph.Node{}, false
+	}
+
+	var cells []telegraph.Node
+	for i, cell := range strings.Split(line, "|") {
+		if i > 0 {
+			cells = append(cells, telegraph.Node{Text: " | "})
+		}
+		cells = append(cells, p.syntax.parse(strings.TrimSpace(cell))...)
+	}
+	return Element(atom.P, mergeText(cells)...), true
+}
+
+// link parses [[target][description]] and [[target]] links. A target that
+// is an image without a description becomes an inline image.
+func (p *orgParser) link(s string, i int) ([]telegraph.Node, int) {
+	m := orgLink.FindStringSubmatch(s[i:])
+	if m == nil {
+		return nil, 0
+	}
+
+	target := p.target(m[1])
+	if m[2] == "" {
+		if isImagePath(target) {
+			return []telegraph.Node{imageNode(target)}, len(m[0])
+		}
+		return []telegraph.Node{Link(target, telegraph.Node{Text: m[1]})}, len(m[0])
+	}
+	return []telegraph.Node{Link(target, p.syntax.parse(m[2])...)}, len(m[0])
+}
+
+// target strips the file: prefix from a link target and resolves relative
+// paths
+func (p *orgParser) target(target string) string {
+	target = strings.TrimPrefix(target, "file:")
+	return resolvePath(p.opts.BaseDir, target)
+}
+
+// isImageLine reports whether a line consists only of a link to an image
+func (p *orgParser) isImageLine(line string) bool {
+	m := orgLink.FindStringSubmatch(line)
+	return m != nil && len(m[0]) == len(line) && m[2] == "" && isImagePath(m[1])
+}
+
+// orgBlock returns the lines of a #+begin_ block and the index of its
+// #+end_ line. An unterminated block runs to the end of the input.
+func orgBlock(lines []string, start int, kind string) ([]string, int) {
+	end := "#+end_" + kind
+	for i := start + 1; i < len(lines); i++ {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(lines[i])), end) {
+			return lines[start+1 : i], i
+		}
+	}
+	return lines[start+1:], len(lines)
+}
+
+// orgCheckbox returns the text used for a list item checkbox
+func orgCheckbox(state string) string {
+	switch state {
+	case " ", "-":
+		return "☐ "
+	case "X", "x":
+		return "☑ "
+	}
+	return ""
+}
+
+func init() {
+	Register("org", ConverterFunc(ParseOrg), ".org")
+}
@@ -0,0 +1,57 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOrgMatchesMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		org      string
+		markdown string
+	}{
+		{"headings", "* Title\n** Section\n*** Subsection", "# Title\n## Section\n### Subsection"},
+		{"TODO keyword and tags", "* TODO Title :work:", "# Title"},
+		{"paragraph", "Some\ntext.", "Some\ntext."},
+		{"bullet list", "- a\n- b", "- a\n- b"},
+		{"ordered list", "1. a\n2. b", "1. a\n2. b"},
+		{"list after paragraph", "Text.\n- a\n- b", "Text.\n\n- a\n- b"},
+		{"paragraph after list", "- a\n\nText.", "- a\n\nText."},
+		{"checkboxes", "- [ ] a\n- [X] b", "- [ ] a\n- [x] b"},
+		{"source block", "#+begin_src go\nfunc f() {\n\treturn\n}\n#+end_src", "```go\nfunc f() {\n\treturn\n}\n```"},
+		{"indented source block", "#+BEGIN_SRC\n  x\n    y\n#+END_SRC", "```\nx\n  y\n```"},
+		{"tab indented source block", "#+begin_example\n\tx\n    \ty\n  \t  z\n#+end_example", "```\nx\ny\n  z\n```"},
+		{"mixed indentation", "#+begin_src\n  \tx\n    y\n#+end_src", "```\n    x\ny\n```"},
+		{"fixed-width lines", ": a\n:  b", "```\na\n b\n```"},
+		{"quote", "#+begin_quote\nQuoted.\n#+end_quote", "> Quoted."},
+		{"link", "See [[https://example.com][the site]].", "See [the site](https://example.com)."},
+		{"bare link", "[[https://example.com]]", "[https://example.com](https://example.com)"},
+		{"autolink", "Go to https://example.com.", "Go to <https://example.com>."},
+		{"image", "[[file:img.png]]", "![](img.png)"},
+		{"image after paragraph", "Text.\n[[./img.png]]", "Text.\n\n![](./img.png)"},
+		{"inline image", "A [[img.png]] b", "A ![](img.png) b"},
+		{"emphasis", "*bold* /italic/ +struck+ =code= ~verb~", "**bold** *italic* ~~struck~~ `code` `verb`"},
+		{"nested emphasis", "*bold /italic/*", "**bold *italic***"},
+		{"intraword markup", "a*b*c snake_case_name", "a\\*b\\*c snake_case_name"},
+		{"rule", "-----", "***"},
+		{"comments and keywords", "#+TITLE: Doc\n# comment\nText.", "Text."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOrg(strings.NewReader(tt.org), Options{})
+			if err != nil {
+				t.Fatalf("ParseOrg(%q) failed: %v", tt.org, err)
+			}
+			want, err := ParseReader(strings.NewReader(tt.markdown), Options{})
+			if err != nil {
+				t.Fatalf("ParseReader(%q) failed: %v", tt.markdown, err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ParseOrg(%q)\n got: %s\nwant: %s", tt.org, dump(got), dump(want))
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package markdown
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	rstListItem  = regexp.MustCompile(`^(\s*)([-*+•]|\d+[.)]|#[.)]|\(\d+\))\s+(.*)$`)
+	rstDirective = regexp.MustCompile(`^\.\.\s+([\w-]+)::\s*(.*)$`)
+	rstOption    = regexp.MustCompile(`^:([\w-]+):\s*(.*)$`)
+	rstLink      = regexp.MustCompile("^`([^`<]*?)\\s*<([^>`]+)>`__?")
+	rstReference = regexp.MustCompile("^`([^`]+)`__?")
+	rstRole      = regexp.MustCompile("^:([\\w-]+):`([^`]+)`")
+)
+
+// ParseRST converts a practical subset of reStructuredText to telegraph
+// nodes: section titles, paragraphs, bullet and enumerated lists, literal
+// blocks, code, image and figure directives, block quotes, admonitions,
+// transitions, hyperlinks and basic inline markup. Other directives,
+// comments and hyperlink targets are dropped.
+func ParseRST(r io.Reader, opts Options) ([]telegraph.Node, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &rstParser{opts: opts}
+	p.syntax = inlineSyntax{
+		spans: []span{
+			{open: "``", close: "``", tag: atom.Code, literal: true},
+			{open: "**", close: "**", tag: atom.Strong},
+			{open: "*", close: "*", tag: atom.Em},
+		},
+		link: p.link,
+	}
+
+	return p.blocks(lines), nil
+}
+
+// rstParser converts reStructuredText lines to telegraph nodes
+type rstParser struct {
+	opts   Options
+	syntax inlineSyntax
+	// styles records section adornment styles in order of appearance, which
+	// determines the section level
+	styles []string
+}
+
+// blocks converts a sequence of lines to block nodes
+func (p *rstParser) blocks(lines []string) []telegraph.Node {
+	b := &blockBuilder{inline: p.syntax.parse}
+	literalNext := false
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			b.flush()
+
+		case indentation(line) > 0 && len(b.paragraph) == 0:
+			body, end := indentedBlock(lines, i, 0)
+			if literalNext {
+				b.add(codeNode(strings.Join(body, "\n")))
+			} else {
+				b.add(Element(atom.Blockquote, p.blocks(body)...))
+			}
+			literalNext = false
+			i = end - 1
+
+		case rstDirective.MatchString(trimmed):
+			m := rstDirective.FindStringSubmatch(trimmed)
+			body, end := indentedBlock(lines, i+1, 0)
+			b.add(p.directive(strings.ToLower(m[1]), m[2], body)...)
+			i = end - 1
+
+		case strings.HasPrefix(trimmed, ".."):
+			// Comments and hyperlink targets
+			b.flush()
+			_, end := indentedBlock(lines, i+1, 0)
+			i = end - 1
+
+		case len(b.paragraph) == 0 && isAdornment(trimmed) && i+2 < len(lines) &&
+			isAdornment(strings.TrimSpace(lines[i+2])) && strings.TrimSpace(lines[i+2]) == trimmed:
+			// Section title with overline
+			title := strings.TrimSpace(lines[i+1])
+			b.add(headingNode(p.level("over"+trimmed[:1]), p.syntax.parse(title)))
+			i += 2
+
+		case len(b.paragraph) == 0 && i+1 < len(lines) && isAdornment(strings.TrimSpace(lines[i+1])) &&
+			utf8.RuneCountInString(strings.TrimSpace(lines[i+1])) >= utf8.RuneCountInString(trimmed):
+			// Section title with underline
+			b.add(headingNode(p.level(strings.TrimSpace(lines[i+1])[:1]), p.syntax.parse(trimmed)))
+			i++
+
+		case len(b.paragraph) == 0 && isAdornment(trimmed) && len(trimmed) >= 4:
+			// Transition
+			b.add(Element(atom.Hr))
+
+		case len(b.paragraph) == 0 && rstListItem.MatchString(line):
+			list, end := p.list(lines, i)
+			b.add(list)
+			i = end - 1
+
+		default:
+			text, literal := literalMarker(line)
+			literalNext = literal
+			if literal && text == "" {
+				b.flush()
+				continue
+			}
+			b.paragraph = append(b.paragraph, text)
+		}
+	}
+
+	return b.result()
+}
+
+// literalMarker handles a paragraph line ending in "::", which introduces a
+// literal block. It returns the text to keep and whether the marker was
+// found.
+func literalMarker(line string) (string, bool) {
+	trimmed := strings.TrimRight(line, " ")
+	if !strings.HasSuffix(trimmed, "::") {
+		return line, false
+	}
+	switch {
+	case strings.TrimSpace(trimmed) == "::":
+		return "", true
+	case strings.HasSuffix(trimmed, " ::"):
+		return strings.TrimSuffix(trimmed, " ::"), true
+	}
+	return strings.TrimSuffix(trimmed, ":"), true
+}
+
+// directive converts a directive with its argument and indented body
+func (p *rstParser) directive(name, arg string, body []string) []telegraph.Node {
+	options, content := directiveOptions(body)
+
+	switch name {
+	case "image":
+		return []telegraph.Node{figureNode(resolvePath(p.opts.BaseDir, arg), options["alt"])}
+	case "figure":
+		caption := joinParagraph(firstParagraph(content))
+		if caption == "" {
+			caption = options["alt"]
+		}
+		return []telegraph.Node{figureNode(resolvePath(p.opts.BaseDir, arg), caption)}
+	case "code", "code-block", "sourcecode":
+		return []telegraph.Node{codeNode(strings.Join(content, "\n"))}
+	case "note", "tip", "hint", "important", "warning", "caution", "attention", "danger", "error", "admonition":
+		nodes := p.blocks(content)
+		if arg != "" {
+			nodes = append([]telegraph.Node{Element(atom.P, Element(atom.Strong, p.syntax.parse(arg)...))}, nodes...)
+		}
+		return []telegraph.Node{Element(atom.Blockquote, nodes...)}
+	}
+	return nil
+}
+
+// list reads a bullet or enumerated list starting at lines[start]. Nested
+// items are flattened.
+func (p *rstParser) list(lines []string, start int) (telegraph.Node, int) {
+	ordered := isEnumerator(rstListItem.FindStringSubmatch(lines[start])[2])
+
+	var items [][]string
+	i := start
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if m := rstListItem.FindStringSubmatch(line); m != nil {
+			if isEnumerator(m[2]) != ordered {
+				break
+			}
+			items = append(items, []string{m[3]})
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			if i+1 < len(lines) && (rstListItem.MatchString(lines[i+1]) || indentation(lines[i+1]) > 0) {
+				continue
+			}
+			break
+		}
+		if indentation(line) == 0 {
+			break
+		}
+		items[len(items)-1] = append(items[len(items)-1], line)
+	}
+
+	content := make([][]telegraph.Node, 0, len(items))
+	for _, item := range items {
+		content = append(content, p.syntax.parse(joinParagraph(item)))
+	}
+	return listNode(ordered, content), i
+}
+
+// link parses hyperlinks, references and interpreted text roles
+func (p *rstParser) link(s string, i int) ([]telegraph.Node, int) {
+	rest := s[i:]
+
+	if m := rstLink.FindStringSubmatch(rest); m != nil {
+		text := m[1]
+		if text == "" {
+			text = m[2]
+		}
+		href := resolvePath(p.opts.BaseDir, m[2])
+		return []telegraph.Node{Link(href, telegraph.Node{Text: text})}, len(m[0])
+	}
+
+	if m := rstRole.FindStringSubmatch(rest); m != nil {
+		switch m[1] {
+		case "code", "literal", "samp", "file":
+			return []telegraph.Node{Element(atom.Code, telegraph.Node{Text: m[2]})}, len(m[0])
+		case "strong":
+			return []telegraph.Node{Element(atom.Strong, telegraph.Node{Text: m[2]})}, len(m[0])
+		case "emphasis":
+			return []telegraph.Node{Element(atom.Em, telegraph.Node{Text: m[2]})}, len(m[0])
+		}
+		return []telegraph.Node{{Text: m[2]}}, len(m[0])
+	}
+
+	if strings.HasPrefix(rest, "``") {
+		return nil, 0
+	}
+	if m := rstReference.FindStringSubmatch(rest); m != nil {
+		// References to named targets keep their text
+		return []telegraph.Node{{Text: m[1]}}, len(m[0])
+	}
+
+	return nil, 0
+}
+
+// isEnumerator reports whether a list marker belongs to an enumerated list
+func isEnumerator(marker string) bool {
+	return !strings.ContainsAny(marker, "-*+•")
+}
+
+// level returns the section level for an adornment style
+func (p *rstParser) level(style string) int {
+	for i, s := range p.styles {
+		if s == style {
+			return i + 1
+		}
+	}
+	p.styles = append(p.styles, style)
+	return len(p.styles)
+}
+
+// isAdornment reports whether a line is a section adornment or transition:
+// at least two repetitions of one punctuation character
+func isAdornment(line string) bool {
+	if len(line) < 2 || !strings.ContainsRune("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", rune(line[0])) {
+		return false
+	}
+	return strings.Count(line, line[:1]) == len(line)
+}
+
+// directiveOptions splits a directive body into its field list options and
+// the remaining content
+func directiveOptions(body []string) (map[string]string, []string) {
+	options := map[string]string{}
+	i := 0
+	for ; i < len(body); i++ {
+		m := rstOption.FindStringSubmatch(strings.TrimSpace(body[i]))
+		if m == nil {
+			break
+		}
+		options[m[1]] = m[2]
+	}
+	for i < len(body) && strings.TrimSpace(body[i]) == "" {
+		i++
+	}
+	return options, body[i:]
+}
+
+// firstParagraph returns the lines up to the first blank line
+func firstParagraph(lines []string) []string {
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			return lines[:i]
+		}
+	}
+	return lines
+}
+
+func init() {
+	Register("rst", ConverterFunc(ParseRST), ".rst", ".rest")
+}
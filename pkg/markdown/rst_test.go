@@ -0,0 +1,56 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRSTMatchesMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		rst      string
+		markdown string
+	}{
+		{"headings", "Title\n=====\n\nSection\n-------\n\nSubsection\n~~~~~~~~~~", "# Title\n## Section\n### Subsection"},
+		{"overlined title", "=====\nTitle\n=====\n\nSection\n=======", "# Title\n## Section"},
+		{"paragraph", "Some\ntext.", "Some\ntext."},
+		{"bullet list", "- a\n- b", "- a\n- b"},
+		{"enumerated list", "1. a\n2. b", "1. a\n2. b"},
+		{"auto-enumerated list", "#. a\n#. b", "1. a\n2. b"},
+		{"list after paragraph", "Text.\n\n* a\n* b", "Text.\n\n- a\n- b"},
+		{"paragraph after list", "- a\n\nText.", "- a\n\nText."},
+		{"literal block", "Example::\n\n    x := 1\n      y", "Example:\n\n```\nx := 1\n  y\n```"},
+		{"expanded literal marker", "Example ::\n\n    code", "Example\n\n```\ncode\n```"},
+		{"bare literal marker", "::\n\n    code", "```\ncode\n```"},
+		{"tab indented literal block", "::\n\n\tx\n\t  y", "```\nx\n  y\n```"},
+		{"code directive", ".. code-block:: go\n\n   func f() {}", "```go\nfunc f() {}\n```"},
+		{"block quote", "Text.\n\n    Quoted.", "Text.\n\n> Quoted."},
+		{"link", "See `the site <https://example.com>`_.", "See [the site](https://example.com)."},
+		{"anonymous link", "`the site <https://example.com>`__", "[the site](https://example.com)"},
+		{"autolink", "Go to https://example.com.", "Go to <https://example.com>."},
+		{"image", ".. image:: img.png", "![](img.png)"},
+		{"image with alt", ".. image:: img.png\n   :alt: A cat", "![A cat](img.png)"},
+		{"figure", ".. figure:: img.png\n\n   A cat.", "![A cat.](img.png)"},
+		{"emphasis", "**bold** *italic* ``code``", "**bold** *italic* `code`"},
+		{"intraword markup", "a*b*c", "a\\*b\\*c"},
+		{"transition", "Text.\n\n----\n\nMore.", "Text.\n\n***\n\nMore."},
+		{"comment", ".. A comment\n   over two lines\n\nText.", "Text."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRST(strings.NewReader(tt.rst), Options{})
+			if err != nil {
+				t.Fatalf("ParseRST(%q) failed: %v", tt.rst, err)
+			}
+			want, err := ParseReader(strings.NewReader(tt.markdown), Options{})
+			if err != nil {
+				t.Fatalf("ParseReader(%q) failed: %v", tt.markdown, err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ParseRST(%q)\n got: %s\nwant: %s", tt.rst, dump(got), dump(want))
+			}
+		})
+	}
+}
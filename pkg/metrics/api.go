@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIStats counts the requests made to the Telegraph API per method
+type APIStats struct {
+	mu      sync.Mutex
+	methods map[string]*methodStats
+}
+
+type methodStats struct {
+	requests float64
+	errors   float64
+	seconds  float64
+}
+
+// NewAPIStats returns empty API statistics
+func NewAPIStats() *APIStats {
+	return &APIStats{methods: map[string]*methodStats{}}
+}
+
+// Observe records one request
+func (s *APIStats) Observe(method string, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := s.method(method)
+	m.requests++
+	m.seconds += elapsed.Seconds()
+}
+
+// Error records an error returned by an API call. The Telegraph API reports
+// most errors with HTTP 200 and "ok": false, so errors are counted where the
+// call returns them rather than from the HTTP status.
+func (s *APIStats) Error(method string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.method(method).errors++
+}
+
+// method returns the statistics of an API method, adding them if needed
+func (s *APIStats) method(name string) *methodStats {
+	m, ok := s.methods[name]
+	if !ok {
+		m = &methodStats{}
+		s.methods[name] = m
+	}
+	return m
+}
+
+// Families returns the request, error and latency metrics
+func (s *APIStats) Families() []Family {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.methods))
+	for name := range s.methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	requests := Family{Name: "telegraph_api_requests_total", Help: "Requests sent to the Telegraph API.", Type: Counter}
+	errors := Family{Name: "telegraph_api_errors_total", Help: "Telegraph API calls that returned an error.", Type: Counter}
+	latency := Family{Name: "telegraph_api_request_duration_seconds", Help: "Time spent on Telegraph API requests.", Type: Summary}
+	for _, name := range names {
+		m := s.methods[name]
+		labels := map[string]string{"method": name}
+		requests.Samples = append(requests.Samples, Sample{Labels: labels, Value: m.requests})
+		errors.Samples = append(errors.Samples, Sample{Labels: labels, Value: m.errors})
+		latency.Samples = append(latency.Samples,
+			Sample{Suffix: "_sum", Labels: labels, Value: m.seconds},
+			Sample{Suffix: "_count", Labels: labels, Value: m.requests},
+		)
+	}
+	return []Family{requests, errors, latency}
+}
+
+// Transport records every request it sends and its latency in APIStats
+type Transport struct {
+	Base  http.RoundTripper
+	Stats *APIStats
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	t.Stats.Observe(apiMethod(req), time.Since(start))
+	return resp, err
+}
+
+// apiMethod returns the API method of a request, the first element of its
+// path such as getPageList
+func apiMethod(req *http.Request) string {
+	method := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 2)[0]
+	if method == "" {
+		return "unknown"
+	}
+	return method
+}
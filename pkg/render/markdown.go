@@ -0,0 +1,311 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+
+	"telegraphcli/pkg/markdown"
+)
+
+// markdownEscaper escapes text that Markdown would otherwise read as markup
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+)
+
+// Markdown renders telegraph nodes as Markdown. Headings become # and ##,
+// the inverse of the Markdown converter, so content published from Markdown
+// renders back to similar source. Tags without a Markdown equivalent, such as
+// aside, u, iframe and video, are written as HTML.
+func Markdown(nodes []telegraph.Node, opts Options) string {
+	m := &mdWriter{opts: opts}
+	var blocks []string
+	var inline []telegraph.Node
+	flush := func() {
+		if len(inline) > 0 {
+			if text := strings.TrimSpace(m.inlines(inline)); text != "" {
+				blocks = append(blocks, escapeLineStart(text))
+			}
+			inline = nil
+		}
+	}
+
+	for _, n := range nodes {
+		if n.Element == nil || !isBlock(n.Element.Tag.Atom()) {
+			inline = append(inline, n)
+			continue
+		}
+		flush()
+		if block := m.block(n); block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	flush()
+
+	if len(blocks) == 0 {
+		return ""
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+// isBlock reports whether a tag starts a new Markdown block
+func isBlock(tag atom.Atom) bool {
+	switch tag {
+	case atom.P, atom.H3, atom.H4, atom.Pre, atom.Blockquote, atom.Aside,
+		atom.Ul, atom.Ol, atom.Figure, atom.Hr, atom.Iframe, atom.Video:
+		return true
+	}
+	return false
+}
+
+// mdWriter renders nodes as Markdown
+type mdWriter struct {
+	opts Options
+}
+
+// url applies the URL rewriting option
+func (m *mdWriter) url(attr, value string) string {
+	if m.opts.URL != nil {
+		return m.opts.URL(attr, value)
+	}
+	return value
+}
+
+// block renders a block element
+func (m *mdWriter) block(n telegraph.Node) string {
+	el := n.Element
+	switch el.Tag.Atom() {
+	case atom.P:
+		return escapeLineStart(strings.TrimSpace(m.inlines(el.Children)))
+	case atom.H3:
+		return "# " + strings.TrimSpace(m.inlines(el.Children))
+	case atom.H4:
+		return "## " + strings.TrimSpace(m.inlines(el.Children))
+	case atom.Hr:
+		return "---"
+	case atom.Pre:
+		code := strings.TrimRight(markdown.Text(n), "\n")
+		fence := "```"
+		for strings.Contains(code, fence) {
+			fence += "`"
+		}
+		return fence + "\n" + code + "\n" + fence
+	case atom.Blockquote:
+		return prefixLines(strings.TrimRight(Markdown(el.Children, m.opts), "\n"), "> ")
+	case atom.Aside:
+		return "<aside>" + strings.TrimSpace(m.inlines(el.Children)) + "</aside>"
+	case atom.Ul, atom.Ol:
+		return m.list(n, "")
+	case atom.Figure:
+		return m.figure(n)
+	case atom.Iframe, atom.Video:
+		return m.embed(n)
+	}
+	return escapeLineStart(strings.TrimSpace(m.inlines(el.Children)))
+}
+
+// blockMarker matches text at the start of a line that Markdown would read
+// as a heading, quote, list item or rule
+var blockMarker = regexp.MustCompile(`^(#{1,6}(\s|$)|>|[-+](\s|$)|\d+[.)](\s|$)|={3,}|-{3,})`)
+
+// escapeLineStart escapes a leading block marker so a paragraph stays a
+// paragraph
+func escapeLineStart(text string) string {
+	if blockMarker.MatchString(text) {
+		return `\` + text
+	}
+	return text
+}
+
+// list renders a list, indenting nested lists under their item
+func (m *mdWriter) list(n telegraph.Node, indent string) string {
+	var lines []string
+	number := 0
+	for _, item := range n.Element.Children {
+		children := []telegraph.Node{item}
+		if item.Element != nil {
+			children = item.Element.Children
+		} else if strings.TrimSpace(item.Text) == "" {
+			continue
+		}
+
+		number++
+		marker := "- "
+		if n.Element.Tag.Atom() == atom.Ol {
+			marker = fmt.Sprintf("%d. ", number)
+		}
+
+		var text []telegraph.Node
+		var nested []string
+		for _, child := range children {
+			if child.Element != nil && (child.Element.Tag.Atom() == atom.Ul || child.Element.Tag.Atom() == atom.Ol) {
+				nested = append(nested, m.list(child, indent+strings.Repeat(" ", len(marker))))
+				continue
+			}
+			text = append(text, child)
+		}
+
+		lines = append(lines, indent+marker+strings.TrimSpace(m.inlines(text)))
+		lines = append(lines, nested...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// figure renders an image figure as an image with the caption as alt text
+func (m *mdWriter) figure(n telegraph.Node) string {
+	var caption string
+	var media []telegraph.Node
+	for _, child := range n.Element.Children {
+		if child.Element == nil {
+			continue
+		}
+		if child.Element.Tag.Atom() == atom.Figcaption {
+			caption = strings.TrimSpace(markdown.Text(child))
+			continue
+		}
+		media = append(media, child)
+	}
+
+	var parts []string
+	for _, child := range media {
+		if child.Element.Tag.Atom() == atom.Img {
+			parts = append(parts, "!["+markdownEscaper.Replace(caption)+"]("+m.url("src", child.Element.Attrs["src"])+")")
+			caption = ""
+			continue
+		}
+		parts = append(parts, m.embed(child))
+	}
+	if caption != "" {
+		parts = append(parts, "<figcaption>"+html.EscapeString(caption)+"</figcaption>")
+	}
+	return strings.Join(parts, "\n")
+}
+
+// embed renders an iframe or video as HTML
+func (m *mdWriter) embed(n telegraph.Node) string {
+	name := n.Element.Tag.Atom().String()
+	return "<" + name + ` src="` + html.EscapeString(m.url("src", n.Element.Attrs["src"])) + `"></` + name + ">"
+}
+
+// inlines renders inline content
+func (m *mdWriter) inlines(nodes []telegraph.Node) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		b.WriteString(m.inline(n))
+	}
+	return b.String()
+}
+
+// inline renders a text node or inline element
+func (m *mdWriter) inline(n telegraph.Node) string {
+	if n.Element == nil {
+		return markdownEscaper.Replace(n.Text)
+	}
+
+	el := n.Element
+	switch el.Tag.Atom() {
+	case atom.Strong, atom.B:
+		return wrap("**", m.inlines(el.Children))
+	case atom.Em, atom.I:
+		return wrap("*", m.inlines(el.Children))
+	case atom.S:
+		return wrap("~~", m.inlines(el.Children))
+	case atom.U:
+		return "<u>" + m.inlines(el.Children) + "</u>"
+	case atom.Code:
+		code := markdown.Text(n)
+		fence := "`"
+		for strings.Contains(code, fence) {
+			fence += "`"
+		}
+		if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+			code = " " + code + " "
+		}
+		return fence + code + fence
+	case atom.A:
+		return "[" + m.inlines(el.Children) + "](" + m.url("href", el.Attrs["href"]) + ")"
+	case atom.Img:
+		return "![](" + m.url("src", el.Attrs["src"]) + ")"
+	case atom.Br:
+		return "\\\n"
+	}
+	return m.inlines(el.Children)
+}
+
+// wrap surrounds text with a delimiter, keeping surrounding spaces outside
+// so the result is still valid emphasis
+func wrap(delim, text string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	start := strings.Index(text, trimmed)
+	return text[:start] + delim + trimmed + delim + text[start+len(trimmed):]
+}
+
+// prefixLines adds a prefix to every line
+func prefixLines(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = strings.TrimRight(prefix, " ")
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Canonical renders telegraph nodes as an indented outline with one element
+// or text node per line. Unlike Markdown it shows every tag and attribute,
+// so it tells apart content that renders the same.
+func Canonical(nodes []telegraph.Node, opts Options) string {
+	var b strings.Builder
+	writeCanonical(&b, nodes, opts, "")
+	return b.String()
+}
+
+func writeCanonical(b *strings.Builder, nodes []telegraph.Node, opts Options, indent string) {
+	// Adjacent text nodes are written as one, since converters split text
+	// differently
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			b.WriteString(indent + fmt.Sprintf("%q", text.String()) + "\n")
+			text.Reset()
+		}
+	}
+
+	for _, n := range nodes {
+		if n.Element == nil {
+			text.WriteString(n.Text)
+			continue
+		}
+		flush()
+
+		b.WriteString(indent + n.Element.Tag.Atom().String())
+		for _, attr := range []string{"href", "src"} {
+			value, ok := n.Element.Attrs[attr]
+			if !ok {
+				continue
+			}
+			if opts.URL != nil {
+				value = opts.URL(attr, value)
+			}
+			b.WriteString(" " + attr + "=" + fmt.Sprintf("%q", value))
+		}
+		b.WriteString("\n")
+		writeCanonical(b, n.Element.Children, opts, indent+"  ")
+	}
+	flush()
+}
@@ -0,0 +1,165 @@
+package render
+
+import (
+	"html"
+	"strings"
+
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+
+	"telegraphcli/pkg/markdown"
+)
+
+// Options controls how telegraph nodes are rendered
+type Options struct {
+	// URL rewrites href and src attribute values. Values are kept as they
+	// are when it is nil.
+	URL func(attr, value string) string
+	// XHTML writes void elements in self-closing form
+	XHTML bool
+}
+
+// voidElements are written without a closing tag
+var voidElements = map[atom.Atom]bool{
+	atom.Br:  true,
+	atom.Hr:  true,
+	atom.Img: true,
+}
+
+// HTML renders telegraph nodes as an HTML fragment. Headings get the anchor
+// Telegraph gives them as id, with a -2, -3 suffix on repeated headings.
+func HTML(nodes []telegraph.Node, opts Options) string {
+	var b strings.Builder
+	ids := map[string]bool{}
+	for _, node := range nodes {
+		writeNode(&b, node, opts, ids)
+	}
+	return b.String()
+}
+
+// writeNode renders a single node, recording heading ids in ids
+func writeNode(b *strings.Builder, n telegraph.Node, opts Options, ids map[string]bool) {
+	if n.Element == nil {
+		b.WriteString(html.EscapeString(n.Text))
+		return
+	}
+
+	tag := n.Element.Tag.Atom()
+	name := tag.String()
+
+	b.WriteString("<" + name)
+	if tag == atom.H3 || tag == atom.H4 {
+		if id := markdown.Anchor(markdown.Text(n)); id != "" {
+			b.WriteString(` id="` + html.EscapeString(markdown.UniqueAnchor(ids, id)) + `"`)
+		}
+	}
+	for _, attr := range []string{"href", "src"} {
+		value, ok := n.Element.Attrs[attr]
+		if !ok {
+			continue
+		}
+		if opts.URL != nil {
+			value = opts.URL(attr, value)
+		}
+		b.WriteString(" " + attr + `="` + html.EscapeString(value) + `"`)
+	}
+	if tag == atom.Iframe {
+		b.WriteString(` allowfullscreen="allowfullscreen"`)
+	}
+	if tag == atom.Video {
+		b.WriteString(` controls="controls"`)
+	}
+
+	if voidElements[tag] {
+		if opts.XHTML {
+			b.WriteString(" />")
+		} else {
+			b.WriteString(">")
+		}
+		return
+	}
+
+	b.WriteString(">")
+	for _, child := range n.Element.Children {
+		writeNode(b, child, opts, ids)
+	}
+	b.WriteString("</" + name + ">")
+}
+
+// Walk calls fn for every node in the tree, parents before children
+func Walk(nodes []telegraph.Node, fn func(n telegraph.Node)) {
+	for _, n := range nodes {
+		fn(n)
+		if n.Element != nil {
+			Walk(n.Element.Children, fn)
+		}
+	}
+}
+
+// Page holds the fields of a standalone HTML page
+type Page struct {
+	Title      string
+	AuthorName string
+	AuthorURL  string
+	// Body is the rendered article content
+	Body string
+	// Head is extra markup added to the document head
+	Head string
+	// Footer is extra markup added after the article
+	Footer string
+}
+
+// Document renders a standalone HTML page styled like telegra.ph
+func Document(p Page) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	b.WriteString(`<meta charset="utf-8">` + "\n")
+	b.WriteString(`<meta name="viewport" content="width=device-width, initial-scale=1">` + "\n")
+	b.WriteString("<title>" + html.EscapeString(p.Title) + "</title>\n")
+	b.WriteString("<style>\n" + Stylesheet + "</style>\n")
+	b.WriteString(p.Head)
+	b.WriteString("</head>\n<body>\n<div class=\"tl_page\">\n<article class=\"tl_article\">\n")
+	b.WriteString("<header>\n<h1>" + html.EscapeString(p.Title) + "</h1>\n")
+	if p.AuthorName != "" {
+		b.WriteString(`<address>`)
+		if p.AuthorURL != "" {
+			b.WriteString(`<a rel="author" href="` + html.EscapeString(p.AuthorURL) + `">` + html.EscapeString(p.AuthorName) + `</a>`)
+		} else {
+			b.WriteString(html.EscapeString(p.AuthorName))
+		}
+		b.WriteString("</address>\n")
+	}
+	b.WriteString("</header>\n")
+	b.WriteString(p.Body)
+	b.WriteString("\n</article>\n")
+	b.WriteString(p.Footer)
+	b.WriteString("</div>\n</body>\n</html>\n")
+	return b.String()
+}
+
+// Stylesheet approximates the look of telegra.ph articles
+const Stylesheet = `html, body { margin: 0; padding: 0; background: #fff; }
+body { color: #000; font-family: Georgia, "Times New Roman", serif; font-size: 18px; line-height: 1.58; }
+.tl_page { max-width: 732px; margin: 0 auto; padding: 21px 21px 64px; }
+.tl_article header h1 { font-family: "Helvetica Neue", Helvetica, Arial, sans-serif; font-size: 32px; line-height: 1.2; margin: 21px 0 12px; }
+.tl_article header address { font-family: "Helvetica Neue", Helvetica, Arial, sans-serif; font-size: 15px; font-style: normal; color: #79828b; margin-bottom: 21px; }
+.tl_article header address a { color: #79828b; }
+.tl_article h3 { font-family: "Helvetica Neue", Helvetica, Arial, sans-serif; font-size: 24px; line-height: 1.25; margin: 32px 0 12px; }
+.tl_article h4 { font-family: "Helvetica Neue", Helvetica, Arial, sans-serif; font-size: 19px; line-height: 1.3; margin: 32px 0 12px; }
+.tl_article p { margin: 0 0 12px; white-space: pre-wrap; }
+.tl_article a { color: #000; text-decoration: underline; }
+.tl_article blockquote { border-left: 3px solid #000; margin: 0 0 16px; padding: 0 0 0 18px; font-style: italic; }
+.tl_article aside { text-align: center; font-style: italic; margin: 0 0 16px; }
+.tl_article pre { font-family: Menlo, Courier, monospace; font-size: 15px; background: #f5f5f5; border-radius: 4px; padding: 8px 12px; white-space: pre-wrap; word-wrap: break-word; margin: 0 0 14px; }
+.tl_article code { font-family: Menlo, Courier, monospace; font-size: 0.85em; background: #f5f5f5; border-radius: 2px; padding: 0 3px; }
+.tl_article pre code { background: none; padding: 0; font-size: inherit; }
+.tl_article figure { margin: 0 0 16px; text-align: center; }
+.tl_article img, .tl_article video, .tl_article iframe { max-width: 100%; }
+.tl_article iframe { width: 100%; aspect-ratio: 16 / 9; border: 0; }
+.tl_article figcaption { font-family: "Helvetica Neue", Helvetica, Arial, sans-serif; font-size: 14px; color: #79828b; margin-top: 8px; }
+.tl_article hr { border: 0; text-align: center; margin: 24px 0; }
+.tl_article hr:after { content: "***"; letter-spacing: 0.5em; color: #79828b; }
+.tl_article ul, .tl_article ol { margin: 0 0 12px; padding-left: 28px; }
+.tl_index li { margin-bottom: 8px; }
+.tl_index .views { color: #79828b; font-size: 14px; }
+`
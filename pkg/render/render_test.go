@@ -0,0 +1,49 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+	"golang.org/x/net/html/atom"
+
+	"telegraphcli/pkg/markdown"
+)
+
+func TestHTMLHeadingIDs(t *testing.T) {
+	nodes := []telegraph.Node{
+		markdown.Element(atom.H3, telegraph.Node{Text: "Intro"}),
+		markdown.Element(atom.H4, telegraph.Node{Text: "Intro 2"}),
+		markdown.Element(atom.H4, telegraph.Node{Text: "Intro"}),
+		markdown.Element(atom.H4, telegraph.Node{Text: "Intro"}),
+	}
+	want := `<h3 id="Intro">Intro</h3><h4 id="Intro-2">Intro 2</h4><h4 id="Intro-3">Intro</h4><h4 id="Intro-4">Intro</h4>`
+	if got := HTML(nodes, Options{}); got != want {
+		t.Errorf("HTML() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHTMLHeadingIDsMatchLinks(t *testing.T) {
+	source := "[first](#intro) [second](#intro-1)\n\n# Intro\n\n## Intro\n"
+	nodes, err := markdown.ParseReader(strings.NewReader(source), markdown.Options{
+		Links: func(string) (string, bool) { return "", false },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hrefs []string
+	Walk(nodes, func(n telegraph.Node) {
+		if n.Element != nil && n.Element.Tag.Atom() == atom.A {
+			hrefs = append(hrefs, n.Element.Attrs["href"])
+		}
+	})
+	if want := []string{"#Intro", "#Intro-2"}; strings.Join(hrefs, " ") != strings.Join(want, " ") {
+		t.Fatalf("links = %q, want %q", hrefs, want)
+	}
+	got := HTML(nodes, Options{})
+	for _, href := range hrefs {
+		if !strings.Contains(got, ` id="`+strings.TrimPrefix(href, "#")+`"`) {
+			t.Errorf("no heading with the id of %s in\n%s", href, got)
+		}
+	}
+}
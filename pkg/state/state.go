@@ -0,0 +1,190 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"telegraphcli/pkg/token"
+)
+
+// StateFile is the name of the sync state file
+const StateFile = "sync.json"
+
+// Entry records a local file that has been published to Telegraph
+type Entry struct {
+	Path     string   `json:"path"`
+	URL      string   `json:"url"`
+	Title    string   `json:"title"`
+	Tags     []string `json:"tags,omitempty"`
+	Category string   `json:"category,omitempty"`
+}
+
+// State maps absolute local file paths to their published pages
+type State struct {
+	Files map[string]Entry `json:"files"`
+	// Indexes maps index names to the pages generated by index build
+	Indexes map[string]Entry `json:"indexes,omitempty"`
+	// TagPages maps lower-cased tags to the pages generated by tags publish
+	TagPages map[string]Entry `json:"tag_pages,omitempty"`
+	// Hashes maps page paths to the hash of the content last published, used
+	// to detect edits made elsewhere
+	Hashes map[string]string `json:"hashes,omitempty"`
+}
+
+// GetStatePath returns the path to the sync state file
+func GetStatePath() (string, error) {
+	tokenPath, err := token.GetTokenPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(tokenPath), StateFile), nil
+}
+
+// Load reads the sync state, returning an empty state if none exists yet
+func Load() (*State, error) {
+	statePath, err := GetStatePath()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &State{Files: map[string]Entry{}}
+	data, err := os.ReadFile(statePath)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %v", err)
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse sync state: %v", err)
+	}
+	if s.Files == nil {
+		s.Files = map[string]Entry{}
+	}
+
+	return s, nil
+}
+
+// Save writes the sync state
+func (s *State) Save() error {
+	statePath, err := GetStatePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %v", err)
+	}
+
+	if err := os.WriteFile(statePath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write sync state: %v", err)
+	}
+
+	return nil
+}
+
+// Lookup returns the entry for a local file
+func (s *State) Lookup(file string) (Entry, bool) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return Entry{}, false
+	}
+
+	e, ok := s.Files[abs]
+	return e, ok
+}
+
+// Set records the entry for a local file
+func (s *State) Set(file string, e Entry) error {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %v", file, err)
+	}
+
+	s.Files[abs] = e
+	return nil
+}
+
+// Index returns the page generated for a named index
+func (s *State) Index(name string) (Entry, bool) {
+	e, ok := s.Indexes[name]
+	return e, ok
+}
+
+// SetIndex records the page generated for a named index
+func (s *State) SetIndex(name string, e Entry) {
+	if s.Indexes == nil {
+		s.Indexes = map[string]Entry{}
+	}
+	s.Indexes[name] = e
+}
+
+// TagPage returns the page generated for a tag
+func (s *State) TagPage(tag string) (Entry, bool) {
+	e, ok := s.TagPages[strings.ToLower(tag)]
+	return e, ok
+}
+
+// SetTagPage records the page generated for a tag
+func (s *State) SetTagPage(tag string, e Entry) {
+	if s.TagPages == nil {
+		s.TagPages = map[string]Entry{}
+	}
+	s.TagPages[strings.ToLower(tag)] = e
+}
+
+// IsGenerated reports whether a page path belongs to a page generated by
+// index build or tags publish
+func (s *State) IsGenerated(path string) bool {
+	for _, e := range s.Indexes {
+		if e.Path == path {
+			return true
+		}
+	}
+	for _, e := range s.TagPages {
+		if e.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// ByPath returns the entry of the file published at a page path
+func (s *State) ByPath(path string) (Entry, bool) {
+	for _, e := range s.Files {
+		if e.Path == path {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// HasTag reports whether the entry has a tag, ignoring case
+func (e Entry) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Hash returns the content hash recorded when a page was last published
+func (s *State) Hash(path string) (string, bool) {
+	h, ok := s.Hashes[path]
+	return h, ok
+}
+
+// SetHash records the content hash of a published page
+func (s *State) SetHash(path, hash string) {
+	if s.Hashes == nil {
+		s.Hashes = map[string]string{}
+	}
+	s.Hashes[path] = hash
+}
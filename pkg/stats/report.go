@@ -0,0 +1,102 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Change is how the views of one page changed over a report's interval
+type Change struct {
+	Path   string `json:"path"`
+	Title  string `json:"title"`
+	Before uint   `json:"before"`
+	After  uint   `json:"after"`
+	Delta  int64  `json:"delta"`
+}
+
+// Report compares two snapshots of the views history
+type Report struct {
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Snapshots int       `json:"snapshots"`
+	Before    uint      `json:"total_before"`
+	After     uint      `json:"total_after"`
+	// Movers are the pages present in both snapshots that gained views,
+	// most first
+	Movers []Change `json:"movers"`
+	// New are the pages that appeared during the interval
+	New []Change `json:"new"`
+	// Stale are the pages present in both snapshots without new views
+	Stale []Change `json:"stale"`
+	// Gone are the pages missing from the later snapshot
+	Gone []Change `json:"gone"`
+}
+
+// NewReport compares the last snapshot taken at or before from with the last
+// one taken at or before to. If there is none before from, the first
+// snapshot of the interval is used.
+func NewReport(snapshots []Snapshot, from, to time.Time) (*Report, error) {
+	start, end, inRange := -1, -1, 0
+	for i, s := range snapshots {
+		if !s.Time.After(from) {
+			start = i
+		}
+		if !s.Time.After(to) {
+			end = i
+		}
+		if s.Time.After(from) && !s.Time.After(to) {
+			inRange++
+		}
+	}
+	if start < 0 && inRange > 0 {
+		start = end - inRange + 1
+	}
+	if start < 0 || end < 0 || start == end {
+		return nil, fmt.Errorf("need at least two snapshots between %s and %s, run stats collect first", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
+	before, after := snapshots[start], snapshots[end]
+	r := &Report{
+		From:      before.Time,
+		To:        after.Time,
+		Snapshots: end - start + 1,
+		Before:    before.Total(),
+		After:     after.Total(),
+		Movers:    []Change{},
+		New:       []Change{},
+		Stale:     []Change{},
+		Gone:      []Change{},
+	}
+
+	old := map[string]PageViews{}
+	for _, p := range before.Pages {
+		old[p.Path] = p
+	}
+	for _, p := range after.Pages {
+		o, ok := old[p.Path]
+		c := Change{Path: p.Path, Title: p.Title, Before: o.Views, After: p.Views, Delta: int64(p.Views) - int64(o.Views)}
+		switch {
+		case !ok:
+			r.New = append(r.New, c)
+		case c.Delta > 0:
+			r.Movers = append(r.Movers, c)
+		default:
+			r.Stale = append(r.Stale, c)
+		}
+		delete(old, p.Path)
+	}
+	for _, p := range before.Pages {
+		if _, ok := old[p.Path]; ok {
+			r.Gone = append(r.Gone, Change{Path: p.Path, Title: p.Title, Before: p.Views})
+		}
+	}
+
+	byDelta := func(changes []Change) {
+		sort.SliceStable(changes, func(i, j int) bool { return changes[i].Delta > changes[j].Delta })
+	}
+	byDelta(r.Movers)
+	byDelta(r.New)
+	sort.SliceStable(r.Stale, func(i, j int) bool { return r.Stale[i].After > r.Stale[j].After })
+	return r, nil
+}
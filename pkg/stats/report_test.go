@@ -0,0 +1,110 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// at returns a time n days after the start of 2026
+func at(n int) time.Time {
+	return time.Date(2026, 1, 1+n, 0, 0, 0, 0, time.UTC)
+}
+
+// snapshot returns a snapshot taken on day n
+func snapshot(n int, pages ...PageViews) Snapshot {
+	return Snapshot{Time: at(n), Pages: pages}
+}
+
+// views returns the view count of a page
+func views(path string, n uint) PageViews {
+	return PageViews{Path: path, Views: n}
+}
+
+func TestNewReportInterval(t *testing.T) {
+	history := []Snapshot{snapshot(1), snapshot(3), snapshot(5), snapshot(7)}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		// want is the days of the compared snapshots and their count, or
+		// nil for an error
+		want []int
+	}{
+		{"whole history", at(1), at(7), []int{1, 7, 4}},
+		{"from before the history", at(0), at(7), []int{1, 7, 4}},
+		{"to after the history", at(1), at(9), []int{1, 7, 4}},
+		{"from between snapshots", at(2), at(5), []int{1, 5, 3}},
+		{"to between snapshots", at(3), at(6), []int{3, 5, 2}},
+		{"from before the history, to between snapshots", at(0), at(4), []int{1, 3, 2}},
+		{"snapshots at the bounds", at(3), at(5), []int{3, 5, 2}},
+		{"single snapshot", at(0), at(1), nil},
+		{"no snapshot in the interval", at(3), at(4), nil},
+		{"before the history", at(-2), at(0), nil},
+		{"after the history", at(7), at(9), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewReport(history, tt.from, tt.to)
+			if tt.want == nil {
+				if err == nil {
+					t.Fatalf("NewReport() compared %s and %s, want an error", r.From, r.To)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewReport() failed: %v", err)
+			}
+			if !r.From.Equal(at(tt.want[0])) || !r.To.Equal(at(tt.want[1])) || r.Snapshots != tt.want[2] {
+				t.Errorf("NewReport() compared %s and %s over %d snapshots, want days %v", r.From, r.To, r.Snapshots, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewReportChanges(t *testing.T) {
+	history := []Snapshot{
+		snapshot(1, views("up", 10), views("flat", 5), views("gone", 7), views("more", 1), views("top", 50)),
+		snapshot(2, views("up", 15), views("flat", 5), views("more", 31), views("new", 3), views("newer", 8), views("top", 50)),
+	}
+	r, err := NewReport(history, at(1), at(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Before != 73 || r.After != 112 {
+		t.Errorf("totals = %d, %d, want 73, 112", r.Before, r.After)
+	}
+	paths := func(changes []Change) []string {
+		var p []string
+		for _, c := range changes {
+			p = append(p, c.Path)
+		}
+		return p
+	}
+	for _, tt := range []struct {
+		name    string
+		changes []Change
+		want    []string
+	}{
+		{"movers", r.Movers, []string{"more", "up"}},
+		{"new", r.New, []string{"newer", "new"}},
+		{"stale", r.Stale, []string{"top", "flat"}},
+		{"gone", r.Gone, []string{"gone"}},
+	} {
+		if got := paths(tt.changes); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if c := r.Movers[0]; c.Before != 1 || c.After != 31 || c.Delta != 30 {
+		t.Errorf("mover = %+v, want 1 -> 31", c)
+	}
+	if c := r.New[0]; c.Before != 0 || c.Delta != 8 {
+		t.Errorf("new page = %+v, want 0 -> 8", c)
+	}
+	if c := r.Gone[0]; c.Before != 7 || c.After != 0 || c.Delta != 0 {
+		t.Errorf("gone page = %+v, want 7 views before", c)
+	}
+}
@@ -0,0 +1,114 @@
+package stats
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"telegraphcli/pkg/token"
+)
+
+// StatsFile is the name of the views history file. It holds one JSON
+// snapshot per line so collecting only ever appends to it.
+const StatsFile = "stats.jsonl"
+
+// Snapshot is the view count of every page at one point in time
+type Snapshot struct {
+	Time  time.Time   `json:"time"`
+	Pages []PageViews `json:"pages"`
+}
+
+// PageViews is the total view count of one page
+type PageViews struct {
+	Path  string `json:"path"`
+	Title string `json:"title"`
+	Views uint   `json:"views"`
+}
+
+// Total returns the views of all pages in the snapshot
+func (s Snapshot) Total() uint {
+	var total uint
+	for _, p := range s.Pages {
+		total += p.Views
+	}
+	return total
+}
+
+// GetStatsPath returns the path to the views history file
+func GetStatsPath() (string, error) {
+	tokenPath, err := token.GetTokenPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(tokenPath), StatsFile), nil
+}
+
+// Append adds a snapshot to the end of the views history
+func Append(s Snapshot) error {
+	statsPath, err := GetStatsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(statsPath), 0700); err != nil {
+		return fmt.Errorf("failed to create stats directory: %v", err)
+	}
+
+	line, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %v", err)
+	}
+
+	f, err := os.OpenFile(statsPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open stats: %v", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write stats: %v", err)
+	}
+	return f.Close()
+}
+
+// Load reads every snapshot of the views history, oldest first
+func Load() ([]Snapshot, error) {
+	statsPath, err := GetStatsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(statsPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stats: %v", err)
+	}
+	defer f.Close()
+
+	var snapshots []Snapshot
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var s Snapshot
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			return nil, fmt.Errorf("failed to parse %s line %d: %v", statsPath, n, err)
+		}
+		snapshots = append(snapshots, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stats: %v", err)
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Time.Before(snapshots[j].Time)
+	})
+	return snapshots, nil
+}
@@ -0,0 +1,201 @@
+package trash
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	telegraph "source.toby3d.me/toby3d/telegraph/v2"
+
+	"telegraphcli/pkg/history"
+	"telegraphcli/pkg/token"
+)
+
+// TrashDir is the name of the directory holding deleted pages
+const TrashDir = "trash"
+
+// Item is a page as it was before it was deleted
+type Item struct {
+	Path       string
+	URL        string
+	Title      string
+	AuthorName string
+	AuthorURL  string
+	DeletedAt  time.Time
+	Content    []telegraph.Node
+}
+
+// itemFile is the stored form of an item
+type itemFile struct {
+	Path       string          `json:"path"`
+	URL        string          `json:"url"`
+	Title      string          `json:"title"`
+	AuthorName string          `json:"author_name,omitempty"`
+	AuthorURL  string          `json:"author_url,omitempty"`
+	DeletedAt  time.Time       `json:"deleted_at"`
+	Content    json.RawMessage `json:"content"`
+}
+
+// GetTrashPath returns the directory holding deleted pages
+func GetTrashPath() (string, error) {
+	tokenPath, err := token.GetTokenPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(tokenPath), TrashDir), nil
+}
+
+// Put moves a page into the trash, replacing an earlier item for the same path
+func Put(item Item) error {
+	dir, err := GetTrashPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create trash directory: %v", err)
+	}
+	if item.DeletedAt.IsZero() {
+		item.DeletedAt = time.Now()
+	}
+
+	content, err := history.EncodeNodes(item.Content)
+	if err != nil {
+		return fmt.Errorf("failed to encode page: %v", err)
+	}
+	data, err := json.MarshalIndent(itemFile{
+		Path:       item.Path,
+		URL:        item.URL,
+		Title:      item.Title,
+		AuthorName: item.AuthorName,
+		AuthorURL:  item.AuthorURL,
+		DeletedAt:  item.DeletedAt.UTC(),
+		Content:    content,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode page: %v", err)
+	}
+
+	if err := os.WriteFile(itemPath(dir, item.Path), data, 0600); err != nil {
+		return fmt.Errorf("failed to write trash: %v", err)
+	}
+	return nil
+}
+
+// Get returns the trashed page with the given path
+func Get(path string) (Item, error) {
+	dir, err := GetTrashPath()
+	if err != nil {
+		return Item{}, err
+	}
+
+	data, err := os.ReadFile(itemPath(dir, path))
+	if os.IsNotExist(err) {
+		return Item{}, fmt.Errorf("%s is not in the trash", path)
+	}
+	if err != nil {
+		return Item{}, fmt.Errorf("failed to read trash: %v", err)
+	}
+	return parse(data)
+}
+
+// List returns the trashed pages, most recently deleted first
+func List() ([]Item, error) {
+	dir, err := GetTrashPath()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trash: %v", err)
+	}
+
+	var items []Item
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read trash: %v", err)
+		}
+		item, err := parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", e.Name(), err)
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+// Remove deletes a page from the trash
+func Remove(path string) error {
+	dir, err := GetTrashPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(itemPath(dir, path)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s from trash: %v", path, err)
+	}
+	return nil
+}
+
+// Purge removes the pages deleted before the given time and returns them
+func Purge(before time.Time) ([]Item, error) {
+	items, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	var purged []Item
+	for _, item := range items {
+		if !item.DeletedAt.Before(before) {
+			continue
+		}
+		if err := Remove(item.Path); err != nil {
+			return purged, err
+		}
+		purged = append(purged, item)
+	}
+	return purged, nil
+}
+
+// parse decodes a stored item
+func parse(data []byte) (Item, error) {
+	var f itemFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return Item{}, err
+	}
+	content, err := history.DecodeNodes(f.Content)
+	if err != nil {
+		return Item{}, err
+	}
+
+	return Item{
+		Path:       f.Path,
+		URL:        f.URL,
+		Title:      f.Title,
+		AuthorName: f.AuthorName,
+		AuthorURL:  f.AuthorURL,
+		DeletedAt:  f.DeletedAt,
+		Content:    content,
+	}, nil
+}
+
+// itemPath returns the file a trashed page is stored in
+func itemPath(dir, path string) string {
+	return filepath.Join(dir, url.PathEscape(path)+".json")
+}
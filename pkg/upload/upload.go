@@ -0,0 +1,73 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+const (
+	// Endpoint is the Telegraph file upload URL
+	Endpoint = "https://telegra.ph/upload"
+	// BaseURL is prepended to the paths returned by the upload endpoint
+	BaseURL = "https://telegra.ph"
+)
+
+// Upload sends an image to Telegraph and returns its URL
+func Upload(ctx context.Context, client *http.Client, name string, data []byte, contentType string) (string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, name))
+	header.Set("Content-Type", contentType)
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return "", fmt.Errorf("failed to create upload form: %v", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return "", fmt.Errorf("failed to create upload form: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("failed to create upload form: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, Endpoint, &body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create upload request: %v", err)
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload %s: %v", name, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read upload response: %v", err)
+	}
+
+	// The endpoint answers with [{"src": "/file/..."}] or {"error": "..."}
+	var files []struct {
+		Src string `json:"src"`
+	}
+	if err := json.Unmarshal(respBody, &files); err != nil || len(files) == 0 {
+		var uploadErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(respBody, &uploadErr) == nil && uploadErr.Error != "" {
+			return "", fmt.Errorf("failed to upload %s: %s", name, uploadErr.Error)
+		}
+		return "", fmt.Errorf("failed to upload %s: unexpected response %s (%s)", name, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	return BaseURL + files[0].Src, nil
+}
@@ -0,0 +1,63 @@
+package views
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// sparkBlocks are the characters of a sparkline, from lowest to highest
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline returns a one-line chart of values
+func Sparkline(values []uint) string {
+	max := maxValue(values)
+
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if max > 0 {
+			i = int(v * uint(len(sparkBlocks)-1) / max)
+		}
+		b.WriteRune(sparkBlocks[i])
+	}
+	return b.String()
+}
+
+// Bars writes a horizontal bar chart with one labelled bar per value, the
+// longest bar being width characters
+func Bars(w io.Writer, labels []string, values []uint, width int) error {
+	max := maxValue(values)
+	labelWidth := 0
+	for _, l := range labels {
+		if n := utf8.RuneCountInString(l); n > labelWidth {
+			labelWidth = n
+		}
+	}
+
+	for i, v := range values {
+		n := 0
+		if max > 0 {
+			n = int(v * uint(width) / max)
+		}
+		if n == 0 && v > 0 {
+			n = 1
+		}
+		pad := strings.Repeat(" ", labelWidth-utf8.RuneCountInString(labels[i]))
+		if _, err := fmt.Fprintf(w, "%s%s │%s %d\n", labels[i], pad, strings.Repeat("█", n), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func maxValue(values []uint) uint {
+	var max uint
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
@@ -0,0 +1,133 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Granularities are the bucket sizes the views API can count
+var Granularities = []string{"hour", "day", "month", "year"}
+
+// Bucket is one period the views API can count: a whole hour, day, month or
+// year in UTC
+type Bucket struct {
+	Start       time.Time
+	Granularity string
+}
+
+// End returns the start of the next bucket
+func (b Bucket) End() time.Time {
+	switch b.Granularity {
+	case "hour":
+		return b.Start.Add(time.Hour)
+	case "day":
+		return b.Start.AddDate(0, 0, 1)
+	case "month":
+		return b.Start.AddDate(0, 1, 0)
+	}
+	return b.Start.AddDate(1, 0, 0)
+}
+
+// Label returns the bucket's start formatted to its granularity
+func (b Bucket) Label() string {
+	switch b.Granularity {
+	case "hour":
+		return b.Start.Format("2006-01-02 15:00")
+	case "day":
+		return b.Start.Format("2006-01-02")
+	case "month":
+		return b.Start.Format("2006-01")
+	}
+	return b.Start.Format("2006")
+}
+
+// Params returns the year, month, day and hour to pass to GetViews, with
+// zero for the parts finer than the granularity
+func (b Bucket) Params() (year, month, day, hour int) {
+	year = b.Start.Year()
+	if b.Granularity == "year" {
+		return
+	}
+	month = int(b.Start.Month())
+	if b.Granularity == "month" {
+		return
+	}
+	day = b.Start.Day()
+	if b.Granularity == "day" {
+		return
+	}
+	return year, month, day, b.Start.Hour()
+}
+
+// ParseDate parses a YYYY-MM-DD date as midnight UTC
+func ParseDate(s string) (time.Time, error) {
+	t, err := time.ParseInLocation("2006-01-02", s, time.UTC)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD", s)
+	}
+	return t, nil
+}
+
+// Buckets returns the buckets of the given granularity that cover the days
+// from and to, both included
+func Buckets(from, to time.Time, granularity string) ([]Bucket, error) {
+	if !validGranularity(granularity) {
+		return nil, fmt.Errorf("unknown granularity %q, use %s", granularity, strings.Join(Granularities, ", "))
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("the range ends before it starts")
+	}
+
+	var buckets []Bucket
+	end := to.AddDate(0, 0, 1)
+	for b := (Bucket{Start: truncate(from, granularity), Granularity: granularity}); b.Start.Before(end); b.Start = b.End() {
+		buckets = append(buckets, b)
+	}
+	return buckets, nil
+}
+
+// Cover returns the fewest buckets that exactly cover the days from and to,
+// both included, using whole years and months where the range allows
+func Cover(from, to time.Time) []Bucket {
+	end := to.AddDate(0, 0, 1)
+
+	var buckets []Bucket
+	for start := from; start.Before(end); {
+		b := Bucket{Start: start, Granularity: "day"}
+		for _, g := range []string{"year", "month"} {
+			c := Bucket{Start: start, Granularity: g}
+			if truncate(start, g).Equal(start) && !c.End().After(end) {
+				b = c
+				break
+			}
+		}
+		buckets = append(buckets, b)
+		start = b.End()
+	}
+	return buckets
+}
+
+// truncate returns the start of the bucket of the given granularity that
+// holds t
+func truncate(t time.Time, granularity string) time.Time {
+	t = t.UTC()
+	switch granularity {
+	case "hour":
+		return t.Truncate(time.Hour)
+	case "day":
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func validGranularity(g string) bool {
+	for _, v := range Granularities {
+		if g == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+//go:build linux
+
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask covers files written in place as well as the rename and
+// delete-and-create sequences editors use when saving
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY | syscall.IN_MOVED_TO | syscall.IN_CREATE | syscall.IN_DELETE
+
+// inotify watches the directories of the watched files
+type inotify struct {
+	w    *Watcher
+	fd   int
+	file *os.File
+
+	mu   sync.Mutex
+	dirs map[string]bool
+	wds  map[int32]string
+}
+
+func newInotify(w *Watcher) (backend, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	// A non-blocking descriptor is handled by the runtime poller, so Close
+	// interrupts a pending Read
+	n := &inotify{w: w, fd: fd, file: os.NewFile(uintptr(fd), "inotify"), dirs: map[string]bool{}, wds: map[int32]string{}}
+	go n.read()
+	return n, nil
+}
+
+func (n *inotify) add(files []string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, f := range files {
+		dir := filepath.Dir(f)
+		if n.dirs[dir] {
+			continue
+		}
+		wd, err := syscall.InotifyAddWatch(n.fd, dir, inotifyMask)
+		if err != nil {
+			return &os.PathError{Op: "watch", Path: dir, Err: err}
+		}
+		n.dirs[dir] = true
+		n.wds[int32(wd)] = dir
+	}
+	return nil
+}
+
+func (n *inotify) close() error {
+	return n.file.Close()
+}
+
+// read reports the events of the watched directories until the descriptor
+// is closed
+func (n *inotify) read() {
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		count, err := n.file.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= count; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			name := strings.TrimRight(string(buf[nameStart:nameStart+int(event.Len)]), "\x00")
+			offset = nameStart + int(event.Len)
+
+			n.mu.Lock()
+			dir, ok := n.wds[event.Wd]
+			n.mu.Unlock()
+			if ok && name != "" {
+				n.w.notify(filepath.Join(dir, name))
+			}
+		}
+	}
+}
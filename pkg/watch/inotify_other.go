@@ -0,0 +1,9 @@
+//go:build !linux
+
+package watch
+
+import "errors"
+
+func newInotify(w *Watcher) (backend, error) {
+	return nil, errors.New("inotify is only available on Linux")
+}
@@ -0,0 +1,80 @@
+package watch
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// poller stats the watched files at an interval
+type poller struct {
+	w        *Watcher
+	interval time.Duration
+	stop     chan struct{}
+	once     sync.Once
+
+	mu    sync.Mutex
+	stats map[string]fileStat
+}
+
+// fileStat is what a file change is detected by; the zero value stands for
+// a missing file
+type fileStat struct {
+	size    int64
+	modTime time.Time
+}
+
+func newPoller(w *Watcher, interval time.Duration) *poller {
+	p := &poller{w: w, interval: interval, stop: make(chan struct{}), stats: map[string]fileStat{}}
+	go p.run()
+	return p
+}
+
+// add records the current state of new files so that only later changes are
+// reported
+func (p *poller) add(files []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, f := range files {
+		if _, ok := p.stats[f]; !ok {
+			p.stats[f] = statFile(f)
+		}
+	}
+	return nil
+}
+
+func (p *poller) close() error {
+	p.once.Do(func() { close(p.stop) })
+	return nil
+}
+
+func (p *poller) run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+
+		for _, f := range p.w.watched() {
+			s := statFile(f)
+			p.mu.Lock()
+			old, known := p.stats[f]
+			p.stats[f] = s
+			p.mu.Unlock()
+			if known && s != old {
+				p.w.notify(f)
+			}
+		}
+	}
+}
+
+func statFile(file string) fileStat {
+	info, err := os.Stat(file)
+	if err != nil {
+		return fileStat{}
+	}
+	return fileStat{size: info.Size(), modTime: info.ModTime()}
+}
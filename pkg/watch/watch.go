@@ -0,0 +1,92 @@
+// Package watch reports changes to a set of files, with inotify on Linux and
+// by polling their size and modification time elsewhere.
+package watch
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// backend delivers change notifications for the directories of watched files
+type backend interface {
+	add(files []string) error
+	close() error
+}
+
+// Watcher reports changes to the files it watches on Events. Changes are
+// coalesced when Events is not read fast enough.
+type Watcher struct {
+	Events chan string
+	// Mode is "inotify" or "polling"
+	Mode string
+
+	mu      sync.Mutex
+	files   map[string]bool
+	backend backend
+}
+
+// New returns a watcher using inotify when available and polling at interval
+// otherwise, or always when poll is set
+func New(interval time.Duration, poll bool) *Watcher {
+	w := &Watcher{Events: make(chan string, 16), files: map[string]bool{}}
+	if !poll {
+		if b, err := newInotify(w); err == nil {
+			w.backend, w.Mode = b, "inotify"
+			return w
+		}
+	}
+	w.backend, w.Mode = newPoller(w, interval), "polling"
+	return w
+}
+
+// Watch replaces the set of watched files
+func (w *Watcher) Watch(files []string) error {
+	abs := make([]string, 0, len(files))
+	set := map[string]bool{}
+	for _, f := range files {
+		a, err := filepath.Abs(f)
+		if err != nil {
+			return err
+		}
+		abs = append(abs, a)
+		set[a] = true
+	}
+
+	w.mu.Lock()
+	w.files = set
+	w.mu.Unlock()
+	return w.backend.add(abs)
+}
+
+// Close stops watching
+func (w *Watcher) Close() error {
+	return w.backend.close()
+}
+
+// watched returns the watched files
+func (w *Watcher) watched() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	files := make([]string, 0, len(w.files))
+	for f := range w.files {
+		files = append(files, f)
+	}
+	return files
+}
+
+// notify reports a change to file if it is watched
+func (w *Watcher) notify(file string) {
+	w.mu.Lock()
+	watched := w.files[file]
+	w.mu.Unlock()
+	if !watched {
+		return
+	}
+
+	select {
+	case w.Events <- file:
+	default:
+	}
+}
@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"telegraphcli/pkg/token"
+)
+
+// DeliveriesFile is the name of the log of handled deliveries, one JSON
+// object per line
+const DeliveriesFile = "webhook.jsonl"
+
+// Outcomes of a delivery
+const (
+	OK      = "ok"
+	Failed  = "failed"
+	Ignored = "ignored"
+)
+
+// Delivery records how one webhook delivery was handled
+type Delivery struct {
+	ID        string            `json:"id"`
+	Time      time.Time         `json:"time"`
+	Event     string            `json:"event"`
+	Repo      string            `json:"repo,omitempty"`
+	Ref       string            `json:"ref,omitempty"`
+	After     string            `json:"after,omitempty"`
+	Outcome   string            `json:"outcome"`
+	Reason    string            `json:"reason,omitempty"`
+	Published []string          `json:"published,omitempty"`
+	Removed   []string          `json:"removed,omitempty"`
+	Errors    map[string]string `json:"errors,omitempty"`
+}
+
+// GetDeliveriesPath returns the path to the deliveries log
+func GetDeliveriesPath() (string, error) {
+	tokenPath, err := token.GetTokenPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(tokenPath), DeliveriesFile), nil
+}
+
+// Append adds a delivery to the end of the log
+func Append(d Delivery) error {
+	deliveriesPath, err := GetDeliveriesPath()
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("failed to encode delivery: %v", err)
+	}
+
+	f, err := os.OpenFile(deliveriesPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open deliveries log: %v", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write deliveries log: %v", err)
+	}
+	return f.Close()
+}
+
+// Load reads every delivery of the log, oldest first
+func Load() ([]Delivery, error) {
+	deliveriesPath, err := GetDeliveriesPath()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(deliveriesPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deliveries log: %v", err)
+	}
+	defer f.Close()
+
+	var deliveries []Delivery
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var d Delivery
+		if err := json.Unmarshal(scanner.Bytes(), &d); err != nil {
+			return nil, fmt.Errorf("failed to parse %s line %d: %v", deliveriesPath, n, err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read deliveries log: %v", err)
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,137 @@
+// Package webhook reads the push events sent by GitHub and Gitea and records
+// the deliveries handled by serve webhook.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ErrSignature is returned for deliveries without a valid signature
+var ErrSignature = errors.New("missing or invalid signature")
+
+// Push is the part of a push event payload serve webhook uses. GitHub, Gitea
+// and Gogs send the same fields.
+type Push struct {
+	Ref        string     `json:"ref"`
+	After      string     `json:"after"`
+	Repository Repository `json:"repository"`
+	Commits    []Commit   `json:"commits"`
+	// Files is an extension for senders that include the content of the
+	// changed files, so no checkout is needed
+	Files []File `json:"files"`
+}
+
+// Repository identifies the pushed repository
+type Repository struct {
+	FullName string `json:"full_name"`
+}
+
+// Commit lists the files changed by one pushed commit
+type Commit struct {
+	ID       string   `json:"id"`
+	Added    []string `json:"added"`
+	Modified []string `json:"modified"`
+	Removed  []string `json:"removed"`
+}
+
+// File is the content of a changed file at the pushed commit
+type File struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// Branch returns the branch name of the pushed ref, or "" for tags
+func (p *Push) Branch() string {
+	branch, ok := strings.CutPrefix(p.Ref, "refs/heads/")
+	if !ok {
+		return ""
+	}
+	return branch
+}
+
+// Changed returns the Markdown files below prefix that exist after the push
+// and the ones that were removed, both sorted. Commits are applied in order,
+// so a file added and later removed counts as removed. Paths are cleaned
+// before the prefix is checked, and paths leaving the repository with ..
+// are kept so that callers can reject them.
+func (p *Push) Changed(prefix string) (changed, removed []string) {
+	status := map[string]bool{}
+	for _, c := range p.Commits {
+		for _, files := range [][]string{c.Added, c.Modified} {
+			for _, f := range files {
+				status[path.Clean(f)] = true
+			}
+		}
+		for _, f := range c.Removed {
+			status[path.Clean(f)] = false
+		}
+	}
+	for _, f := range p.Files {
+		status[path.Clean(f.Path)] = true
+	}
+
+	for f, exists := range status {
+		if !isMarkdown(f) || !underPrefix(f, prefix) {
+			continue
+		}
+		if exists {
+			changed = append(changed, f)
+		} else {
+			removed = append(removed, f)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(removed)
+	return changed, removed
+}
+
+func isMarkdown(file string) bool {
+	ext := strings.ToLower(path.Ext(file))
+	return (ext == ".md" || ext == ".markdown") && !strings.HasSuffix(file, ".remote.md")
+}
+
+func underPrefix(file, prefix string) bool {
+	prefix = strings.Trim(prefix, "/")
+	return prefix == "" || strings.HasPrefix(file, prefix+"/")
+}
+
+// Event returns the event name and delivery ID of a request
+func Event(h http.Header) (event, delivery string) {
+	for _, vendor := range []string{"GitHub", "Gitea", "Gogs"} {
+		if event = h.Get("X-" + vendor + "-Event"); event != "" {
+			return event, h.Get("X-" + vendor + "-Delivery")
+		}
+	}
+	return "", ""
+}
+
+// Verify checks the HMAC-SHA256 signature of a delivery body, sent by GitHub
+// as X-Hub-Signature-256: sha256=<hex> and by Gitea and Gogs as a plain hex
+// digest
+func Verify(secret string, body []byte, h http.Header) error {
+	signature, ok := strings.CutPrefix(h.Get("X-Hub-Signature-256"), "sha256=")
+	if !ok {
+		signature = h.Get("X-Gitea-Signature")
+	}
+	if signature == "" {
+		signature = h.Get("X-Gogs-Signature")
+	}
+
+	got, err := hex.DecodeString(signature)
+	if err != nil || len(got) == 0 {
+		return ErrSignature
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrSignature
+	}
+	return nil
+}
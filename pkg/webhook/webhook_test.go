@@ -0,0 +1,158 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sign returns the hex HMAC-SHA256 of body
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerify(t *testing.T) {
+	const secret, body = "s3cret-s3cret-s3cret", `{"ref":"refs/heads/main"}`
+	valid := sign(secret, body)
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		ok     bool
+	}{
+		{"GitHub", "X-Hub-Signature-256", "sha256=" + valid, true},
+		{"Gitea", "X-Gitea-Signature", valid, true},
+		{"Gogs", "X-Gogs-Signature", valid, true},
+		{"GitHub without prefix", "X-Hub-Signature-256", valid, false},
+		{"GitHub with sha1 prefix", "X-Hub-Signature-256", "sha1=" + valid, false},
+		{"upper case hex", "X-Gitea-Signature", strings.ToUpper(valid), true},
+		{"wrong secret", "X-Gitea-Signature", sign("other", body), false},
+		{"other body", "X-Gitea-Signature", sign(secret, body+" "), false},
+		{"truncated", "X-Gitea-Signature", valid[:len(valid)-2], false},
+		{"not hex", "X-Gitea-Signature", "zz" + valid[2:], false},
+		{"empty", "X-Gitea-Signature", "", false},
+		{"empty GitHub digest", "X-Hub-Signature-256", "sha256=", false},
+		{"no header", "X-Other", valid, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			h.Set(tt.header, tt.value)
+			err := Verify(secret, []byte(body), h)
+			if (err == nil) != tt.ok {
+				t.Errorf("Verify() = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestEvent(t *testing.T) {
+	for _, vendor := range []string{"GitHub", "Gitea", "Gogs"} {
+		h := http.Header{}
+		h.Set("X-"+vendor+"-Event", "push")
+		h.Set("X-"+vendor+"-Delivery", "d1")
+		if event, id := Event(h); event != "push" || id != "d1" {
+			t.Errorf("Event(%s) = %q, %q", vendor, event, id)
+		}
+	}
+	if event, id := Event(http.Header{}); event != "" || id != "" {
+		t.Errorf("Event() without headers = %q, %q", event, id)
+	}
+}
+
+func TestBranch(t *testing.T) {
+	for ref, want := range map[string]string{
+		"refs/heads/main":      "main",
+		"refs/heads/feature/x": "feature/x",
+		"refs/tags/v1.0":       "",
+		"main":                 "",
+	} {
+		if got := (&Push{Ref: ref}).Branch(); got != want {
+			t.Errorf("Branch(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
+
+func TestChanged(t *testing.T) {
+	tests := []struct {
+		name    string
+		push    Push
+		prefix  string
+		changed []string
+		removed []string
+	}{
+		{
+			name: "added and modified",
+			push: Push{Commits: []Commit{
+				{Added: []string{"b.md", "img.png"}, Modified: []string{"a.markdown", "README"}},
+			}},
+			changed: []string{"a.markdown", "b.md"},
+		},
+		{
+			name: "removed later",
+			push: Push{Commits: []Commit{
+				{Added: []string{"a.md", "b.md"}},
+				{Removed: []string{"a.md"}},
+			}},
+			changed: []string{"b.md"},
+			removed: []string{"a.md"},
+		},
+		{
+			name: "added again after removal",
+			push: Push{Commits: []Commit{
+				{Removed: []string{"a.md"}},
+				{Added: []string{"a.md"}},
+			}},
+			changed: []string{"a.md"},
+		},
+		{
+			name:    "remote copies",
+			push:    Push{Commits: []Commit{{Added: []string{"a.remote.md", "a.md"}}}},
+			changed: []string{"a.md"},
+		},
+		{
+			name: "prefix",
+			push: Push{Commits: []Commit{
+				{Added: []string{"docs/a.md", "docsx/b.md", "c.md", "docs/sub/d.md"}, Removed: []string{"docs/old.md", "old.md"}},
+			}},
+			prefix:  "/docs/",
+			changed: []string{"docs/a.md", "docs/sub/d.md"},
+			removed: []string{"docs/old.md"},
+		},
+		{
+			name:   "traversal out of the prefix",
+			push:   Push{Commits: []Commit{{Modified: []string{"docs/../../../home/u/notes.md", "docs/../secret/x.md"}}}},
+			prefix: "docs",
+		},
+		{
+			name:    "traversal is cleaned",
+			push:    Push{Commits: []Commit{{Modified: []string{"docs/../../notes.md", "docs/./a.md", "docs//b.md"}}}},
+			changed: []string{"../notes.md", "docs/a.md", "docs/b.md"},
+		},
+		{
+			name: "payload files",
+			push: Push{
+				Commits: []Commit{{Removed: []string{"a.md"}}},
+				Files:   []File{{Path: "a.md"}, {Path: "b.txt"}},
+			},
+			changed: []string{"a.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed, removed := tt.push.Changed(tt.prefix)
+			if !reflect.DeepEqual(changed, tt.changed) || !reflect.DeepEqual(removed, tt.removed) {
+				t.Errorf("Changed(%q) = %q, %q, want %q, %q", tt.prefix, changed, removed, tt.changed, tt.removed)
+			}
+		})
+	}
+}